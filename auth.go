@@ -2,38 +2,176 @@ package pcloud
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
 	"net/url"
+	"strings"
 )
 
+const resultTwoFactorRequired = 2297
+
 type loginResponse struct {
 	Error
-	Auth string `json:"auth"`
+	Auth   string `json:"auth"`
+	Token  string `json:"token"`
+	UserID uint64 `json:"userid"`
+}
+
+type digestResponse struct {
+	Error
+	Digest string `json:"digest"`
+}
+
+type LoginOpts struct {
+	PlainPassword bool
+	TwoFactor     func(ctx context.Context, token string) (*TwoFactorCode, error)
+}
+
+type TwoFactorCode struct {
+	Code         string
+	RecoveryCode bool
+	TrustDevice  bool
+}
+
+type TwoFactorRequiredError struct {
+	Token string
+}
+
+func (e *TwoFactorRequiredError) Error() string {
+	return "two-factor authentication required"
+}
+
+func passwordDigest(username, password, digest string) string {
+	userHash := sha1.Sum([]byte(strings.ToLower(username)))
+	sum := sha1.Sum([]byte(password + hex.EncodeToString(userHash[:]) + digest))
+	return hex.EncodeToString(sum[:])
 }
 
 func (c *Client) Login(ctx context.Context, username, password string) error {
+	return c.LoginWithOpts(ctx, username, password, nil)
+}
+
+func (c *Client) LoginWithOpts(ctx context.Context, username, password string, opts *LoginOpts) error {
+	c.mu.RLock()
+	autoRegion, current := c.autoRegion, c.baseURL
+	c.mu.RUnlock()
+
+	if !autoRegion {
+		if err := c.login(ctx, username, password, opts); err != nil {
+			return err
+		}
+		return c.saveCredentials(ctx)
+	}
+
+	var err error
+	for _, baseURL := range regionCandidates(current) {
+		attempt := c.withBaseURL(baseURL)
+		err = attempt.login(ctx, username, password, opts)
+		if isWrongRegion(err) {
+			continue
+		}
+		var tfa *TwoFactorRequiredError
+		if errors.As(err, &tfa) {
+			c.pinRegion(baseURL)
+		}
+		if err != nil {
+			return err
+		}
+		c.pinRegion(baseURL)
+		c.setSession(attempt.AuthToken(), attempt.UserID())
+		return c.saveCredentials(ctx)
+	}
+	return err
+}
+
+func (c *Client) login(ctx context.Context, username, password string, opts *LoginOpts) error {
 	params := url.Values{
 		"getauth":  {"1"},
 		"username": {username},
-		"password": {password},
+	}
+
+	if opts != nil && opts.PlainPassword {
+		params.Set("password", password)
+	} else {
+		var digest digestResponse
+		if err := c.do(ctx, "getdigest", url.Values{}, &digest); err != nil {
+			return err
+		}
+		params.Set("digest", digest.Digest)
+		params.Set("passworddigest", passwordDigest(username, password, digest.Digest))
+	}
+
+	var resp loginResponse
+	err := c.do(ctx, "userinfo", params, &resp)
+	if resp.Result == resultTwoFactorRequired {
+		if opts == nil || opts.TwoFactor == nil {
+			return &TwoFactorRequiredError{Token: resp.Token}
+		}
+		code, err := opts.TwoFactor(ctx, resp.Token)
+		if err != nil {
+			return err
+		}
+		return c.loginTwoFactor(ctx, resp.Token, code)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.setSession(resp.Auth, resp.UserID)
+	return nil
+}
+
+func (c *Client) LoginTwoFactor(ctx context.Context, token string, code *TwoFactorCode) error {
+	if err := c.loginTwoFactor(ctx, token, code); err != nil {
+		return err
+	}
+	return c.saveCredentials(ctx)
+}
+
+var errNoTwoFactorCode = errors.New("two-factor code is required")
+
+func (c *Client) loginTwoFactor(ctx context.Context, token string, code *TwoFactorCode) error {
+	if code == nil {
+		return errNoTwoFactorCode
+	}
+	params := url.Values{
+		"token": {token},
+		"code":  {code.Code},
+	}
+	if code.RecoveryCode {
+		params.Set("isrecoverycode", "1")
+	}
+	if code.TrustDevice {
+		params.Set("trustdevice", "1")
 	}
 
 	var resp loginResponse
-	if err := c.do(ctx, "userinfo", params, &resp); err != nil {
+	if err := c.do(ctx, "tfa_login", params, &resp); err != nil {
 		return err
 	}
 
-	c.auth = resp.Auth
+	c.setSession(resp.Auth, resp.UserID)
 	return nil
 }
 
+func (c *Client) SendTwoFactorSMS(ctx context.Context, token string) error {
+	params := url.Values{
+		"token": {token},
+	}
+
+	var resp Error
+	return c.do(ctx, "tfa_sendcodeviasms", params, &resp)
+}
+
 func (c *Client) Logout(ctx context.Context) error {
 	var resp Error
 	if err := c.do(ctx, "logout", url.Values{}, &resp); err != nil {
 		return err
 	}
 
-	c.auth = ""
-	return nil
+	c.setSession("", 0)
+	return c.deleteCredentials(ctx)
 }
 
 func (c *Client) UserInfo(ctx context.Context) (*UserInfo, error) {
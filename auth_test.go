@@ -0,0 +1,104 @@
+package pcloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPasswordDigest(t *testing.T) {
+	// sha1("secret" + sha1("user@example.com") + "abc123")
+	const want = "29cbcb45a7702e4a091ab98e295e3c48c20ded8f"
+	for _, username := range []string{"user@example.com", "User@Example.COM"} {
+		if got := passwordDigest(username, "secret", "abc123"); got != want {
+			t.Errorf("passwordDigest(%q) = %s, want %s", username, got, want)
+		}
+	}
+	if got := passwordDigest("user@example.com", "Secret", "abc123"); got == want {
+		t.Error("password case ignored")
+	}
+}
+
+func TestLoginSendsDigest(t *testing.T) {
+	type call struct {
+		path  string
+		query string
+		form  url.Values
+	}
+	var mu sync.Mutex
+	var calls []call
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		mu.Lock()
+		calls = append(calls, call{r.URL.Path, r.URL.RawQuery, r.Form})
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/getdigest":
+			fmt.Fprint(w, `{"result":0,"digest":"abc123"}`)
+		case "/userinfo":
+			fmt.Fprint(w, `{"result":0,"auth":"session","userid":7}`)
+		default:
+			t.Errorf("unexpected call to %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, WithRateLimit(60000))
+	if err := c.Login(context.Background(), "User@Example.com", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if c.AuthToken() != "session" {
+		t.Errorf("AuthToken() = %q", c.AuthToken())
+	}
+
+	if len(calls) != 2 || calls[0].path != "/getdigest" || calls[1].path != "/userinfo" {
+		t.Fatalf("calls = %+v, want getdigest then userinfo", calls)
+	}
+	login := calls[1].form
+	if login.Get("digest") != "abc123" {
+		t.Errorf("digest = %q", login.Get("digest"))
+	}
+	if login.Get("passworddigest") != passwordDigest("user@example.com", "secret", "abc123") {
+		t.Errorf("passworddigest = %q", login.Get("passworddigest"))
+	}
+	for _, c := range calls {
+		if c.form.Has("password") || strings.Contains(c.query, "secret") {
+			t.Errorf("%s sent the password: query %q, form %v", c.path, c.query, c.form)
+		}
+	}
+}
+
+func TestLoginTwoFactorNilCode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/getdigest":
+			fmt.Fprint(w, `{"result":0,"digest":"abc"}`)
+		case "/userinfo":
+			fmt.Fprint(w, `{"result":2297,"error":"Two-factor authentication required.","token":"tfa-token"}`)
+		default:
+			t.Errorf("unexpected call to %s", r.URL.Path)
+			fmt.Fprint(w, `{"result":0}`)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := NewClient(srv.URL, WithRateLimit(60000))
+
+	if err := c.LoginTwoFactor(ctx, "tfa-token", nil); !errors.Is(err, errNoTwoFactorCode) {
+		t.Errorf("LoginTwoFactor(nil) = %v, want errNoTwoFactorCode", err)
+	}
+
+	err := c.LoginWithOpts(ctx, "user@example.com", "secret", &LoginOpts{
+		TwoFactor: func(context.Context, string) (*TwoFactorCode, error) { return nil, nil },
+	})
+	if !errors.Is(err, errNoTwoFactorCode) {
+		t.Errorf("Login with nil code = %v, want errNoTwoFactorCode", err)
+	}
+}
@@ -0,0 +1,541 @@
+package pcloud
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Value types of the binary protocol. See
+// https://docs.pcloud.com/protocols/binary_protocol/.
+const (
+	binString      = 0   // 0-3: string, length in 1-4 bytes
+	binStringReuse = 4   // 4-7: previously sent string, id in 1-4 bytes
+	binNumber      = 8   // 8-15: number in 1-8 bytes
+	binHash        = 16  // key/value pairs until binEnd
+	binArray       = 17  // values until binEnd
+	binFalse       = 18  //
+	binTrue        = 19  //
+	binData        = 20  // 8 byte length, data follows the response
+	binShortString = 100 // 100-149: string of length type-100
+	binShortReuse  = 150 // 150-199: previously sent string with id type-150
+	binShortNumber = 200 // 200-219: number type-200
+	binEnd         = 255
+
+	binParamString = 0
+	binParamNumber = 1
+	binParamBool   = 2
+
+	binMaxRequest = 1<<16 - 1
+	binPort       = "443"
+)
+
+// BinaryTransport sends API calls over pCloud's native binary protocol
+// instead of JSON over HTTPS. It keeps a small pool of TLS connections per
+// server. File downloads and content endpoints such as thumbnails and zips
+// still use HTTPS.
+type BinaryTransport struct {
+	// Addr is the "host:port" of the binary API server. When empty,
+	// api.pcloud.com uses binapi.pcloud.com:443 and other base URLs ask
+	// getapiserver for their binary server.
+	Addr string
+	// DialContext opens a connection to the server. The default dials TLS.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+	// MaxIdleConns limits pooled connections per server. Zero means 4.
+	MaxIdleConns int
+
+	mu    sync.Mutex
+	idle  map[string][]net.Conn
+	addrs map[string]string
+}
+
+// WithBinaryProtocol makes the client send API calls over the binary
+// protocol. A nil transport uses the defaults.
+func WithBinaryProtocol(t *BinaryTransport) Option {
+	return func(c *Client) {
+		if t == nil {
+			t = &BinaryTransport{}
+		}
+		c.binary = t
+	}
+}
+
+// binaryAddr returns the binary API server for st's base URL. The US API
+// host maps to binapi.pcloud.com; for any other host the server is asked
+// with getapiserver over HTTPS, and the answer is cached per base URL.
+func (st *clientState) binaryAddr(ctx context.Context) (string, error) {
+	u, err := url.Parse(st.baseURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Hostname() == "api.pcloud.com" {
+		return withBinaryPort("binapi.pcloud.com", u.Port()), nil
+	}
+
+	t := st.binary
+	t.mu.Lock()
+	addr, ok := t.addrs[st.baseURL]
+	t.mu.Unlock()
+	if ok {
+		return addr, nil
+	}
+
+	https := *st
+	https.binary = nil
+	resp, err := https.transport(ctx, newCall("getapiserver", CallAPI, url.Values{}))
+	if err != nil {
+		return "", err
+	}
+	var servers APIServers
+	if err := https.decode("getapiserver", resp, &servers); err != nil {
+		return "", err
+	}
+	if len(servers.BinAPI) == 0 {
+		return "", fmt.Errorf("getapiserver: no binary api servers for %s, set BinaryTransport.Addr", u.Host)
+	}
+	addr = withBinaryPort(servers.BinAPI[0], "")
+
+	t.mu.Lock()
+	if t.addrs == nil {
+		t.addrs = make(map[string]string)
+	}
+	t.addrs[st.baseURL] = addr
+	t.mu.Unlock()
+	return addr, nil
+}
+
+// withBinaryPort adds port, or the default binary port, to a host without
+// one.
+func withBinaryPort(host, port string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	if port == "" {
+		port = binPort
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// roundTrip sends one request. When a pooled connection turns out to have
+// been closed by the server before it answered, the request is sent again on
+// a fresh connection.
+func (t *BinaryTransport) roundTrip(ctx context.Context, addr string, req, data []byte) (any, []byte, error) {
+	for {
+		conn, pooled, err := t.dial(ctx, addr)
+		if err != nil {
+			return nil, nil, err
+		}
+		stop := context.AfterFunc(ctx, func() {
+			_ = conn.SetDeadline(time.Now())
+		})
+		value, payload, err := exchangeBinary(conn, req, data)
+		if !stop() {
+			err = errors.Join(err, ctx.Err())
+		}
+		if err == nil {
+			t.release(addr, conn)
+			return value, payload, nil
+		}
+		_ = conn.Close()
+		if !pooled || !errors.Is(err, io.EOF) || ctx.Err() != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+func (t *BinaryTransport) dial(ctx context.Context, addr string) (net.Conn, bool, error) {
+	t.mu.Lock()
+	if conns := t.idle[addr]; len(conns) > 0 {
+		conn := conns[len(conns)-1]
+		t.idle[addr] = conns[:len(conns)-1]
+		t.mu.Unlock()
+		return conn, true, nil
+	}
+	t.mu.Unlock()
+
+	dial := t.DialContext
+	if dial == nil {
+		dial = (&tls.Dialer{}).DialContext
+	}
+	conn, err := dial(ctx, "tcp", addr)
+	return conn, false, err
+}
+
+func (t *BinaryTransport) release(addr string, conn net.Conn) {
+	limit := t.MaxIdleConns
+	if limit <= 0 {
+		limit = 4
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.idle[addr]) >= limit {
+		_ = conn.Close()
+		return
+	}
+	if t.idle == nil {
+		t.idle = map[string][]net.Conn{}
+	}
+	t.idle[addr] = append(t.idle[addr], conn)
+}
+
+// CloseIdleConnections closes pooled connections.
+func (t *BinaryTransport) CloseIdleConnections() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for addr, conns := range t.idle {
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+		delete(t.idle, addr)
+	}
+}
+
+func (st *clientState) binaryTransport(ctx context.Context, call *Call) (*CallResponse, error) {
+	addr := st.binary.Addr
+	if addr == "" {
+		var err error
+		if addr, err = st.binaryAddr(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	params := call.requestParams()
+	data, err := binaryData(call.body, call.contentType, params)
+	if err != nil {
+		return nil, err
+	}
+	req, err := encodeBinaryRequest(call.Method, params, int64(len(data)), data != nil)
+	if err != nil {
+		return nil, err
+	}
+
+	st.logger.Debug("request", "method", call.Method, "protocol", "binary")
+	if err := st.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	value, payload, err := st.binary.roundTrip(ctx, addr, req, data)
+	if err != nil {
+		st.logger.Error("request failed", "method", call.Method, "error", err)
+		return nil, fmt.Errorf("%s: %w", call.Method, err)
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	out := &CallResponse{
+		Result:        binaryResult(value),
+		Host:          addr,
+		Duration:      time.Since(start),
+		ContentLength: int64(len(raw)),
+		Raw:           raw,
+	}
+	if payload != nil {
+		out.Body = io.NopCloser(bytes.NewReader(payload))
+	}
+	st.limiter.observe(out.Result)
+	return out, nil
+}
+
+func binaryResult(value any) int {
+	if m, ok := value.(map[string]any); ok {
+		if n, ok := m["result"].(uint64); ok {
+			return int(n)
+		}
+	}
+	return 0
+}
+
+// binaryData turns an HTTP request body into the binary protocol's data
+// stream. Multipart form fields become parameters and the file part becomes
+// the data; any other body is sent as is.
+func binaryData(body io.Reader, contentType string, params url.Values) ([]byte, error) {
+	if body == nil {
+		return nil, nil
+	}
+	mediaType, mediaParams, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") {
+		data, err := io.ReadAll(body)
+		if data == nil {
+			data = []byte{}
+		}
+		return data, err
+	}
+
+	data := []byte{}
+	mr := multipart.NewReader(body, mediaParams["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return data, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		value, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+		if part.FileName() != "" {
+			data = value
+			continue
+		}
+		params.Set(part.FormName(), string(value))
+	}
+}
+
+func exchangeBinary(conn net.Conn, req, data []byte) (any, []byte, error) {
+	if _, err := conn.Write(req); err != nil {
+		return nil, nil, err
+	}
+	if len(data) > 0 {
+		if _, err := conn.Write(data); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var size [4]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, nil, err
+	}
+	buf := make([]byte, binary.LittleEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, nil, err
+	}
+
+	dec := &binaryDecoder{buf: buf}
+	value, err := dec.decode()
+	if err != nil {
+		return nil, nil, err
+	}
+	if dec.dataLen < 0 {
+		return value, nil, nil
+	}
+	payload := make([]byte, dec.dataLen)
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return nil, nil, err
+	}
+	return value, payload, nil
+}
+
+// encodeBinaryRequest encodes the request header: a 2 byte length, the
+// method name with a flag for attached data, and the typed parameters. The
+// data itself is written after the header and not counted in the length.
+func encodeBinaryRequest(method string, params url.Values, dataLen int64, hasData bool) ([]byte, error) {
+	if len(method) == 0 || len(method) > 127 {
+		return nil, fmt.Errorf("binary protocol: invalid method name length %d", len(method))
+	}
+
+	var b bytes.Buffer
+	b.Write([]byte{0, 0})
+	if hasData {
+		b.WriteByte(byte(len(method)) | 0x80)
+		b.Write(binary.LittleEndian.AppendUint64(nil, uint64(dataLen)))
+	} else {
+		b.WriteByte(byte(len(method)))
+	}
+	b.WriteString(method)
+
+	count := 0
+	for _, values := range params {
+		count += len(values)
+	}
+	if count > 255 {
+		return nil, fmt.Errorf("binary protocol: too many parameters (%d)", count)
+	}
+	b.WriteByte(byte(count))
+	for _, key := range slices.Sorted(maps.Keys(params)) {
+		for _, value := range params[key] {
+			if err := encodeBinaryParam(&b, key, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	req := b.Bytes()
+	if len(req)-2 > binMaxRequest {
+		return nil, fmt.Errorf("binary protocol: request too large (%d bytes)", len(req)-2)
+	}
+	binary.LittleEndian.PutUint16(req, uint16(len(req)-2))
+	return req, nil
+}
+
+func encodeBinaryParam(b *bytes.Buffer, name string, value any) error {
+	if len(name) == 0 || len(name) > 63 {
+		return fmt.Errorf("binary protocol: invalid parameter name %q", name)
+	}
+	switch v := value.(type) {
+	case string:
+		b.WriteByte(binParamString<<6 | byte(len(name)))
+		b.WriteString(name)
+		b.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(v))))
+		b.WriteString(v)
+	case uint64:
+		b.WriteByte(binParamNumber<<6 | byte(len(name)))
+		b.WriteString(name)
+		b.Write(binary.LittleEndian.AppendUint64(nil, v))
+	case bool:
+		b.WriteByte(binParamBool<<6 | byte(len(name)))
+		b.WriteString(name)
+		if v {
+			b.WriteByte(1)
+		} else {
+			b.WriteByte(0)
+		}
+	default:
+		return fmt.Errorf("binary protocol: unsupported parameter type %T", value)
+	}
+	return nil
+}
+
+type binaryDecoder struct {
+	buf     []byte
+	pos     int
+	strings []string
+	dataLen int64
+}
+
+var errBinaryTruncated = errors.New("binary protocol: truncated response")
+
+// decode reads one value. Hashes decode to map[string]any, arrays to []any,
+// numbers to uint64 and data markers to their length.
+func (d *binaryDecoder) decode() (any, error) {
+	d.dataLen = -1
+	return d.value()
+}
+
+func (d *binaryDecoder) value() (any, error) {
+	typ, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case typ <= binString+3:
+		n, err := d.uint(int(typ-binString) + 1)
+		if err != nil {
+			return nil, err
+		}
+		return d.string(int(n))
+	case typ <= binStringReuse+3:
+		id, err := d.uint(int(typ-binStringReuse) + 1)
+		if err != nil {
+			return nil, err
+		}
+		return d.reuse(id)
+	case typ <= binNumber+7:
+		return d.uint(int(typ-binNumber) + 1)
+	case typ == binHash:
+		m := map[string]any{}
+		for {
+			if d.peek() == binEnd {
+				d.pos++
+				return m, nil
+			}
+			key, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("binary protocol: hash key of type %T", key)
+			}
+			if m[name], err = d.value(); err != nil {
+				return nil, err
+			}
+		}
+	case typ == binArray:
+		a := []any{}
+		for {
+			if d.peek() == binEnd {
+				d.pos++
+				return a, nil
+			}
+			v, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+	case typ == binFalse:
+		return false, nil
+	case typ == binTrue:
+		return true, nil
+	case typ == binData:
+		n, err := d.uint(8)
+		if err != nil {
+			return nil, err
+		}
+		d.dataLen = int64(n)
+		return n, nil
+	case typ >= binShortString && typ < binShortReuse:
+		return d.string(int(typ - binShortString))
+	case typ >= binShortReuse && typ < binShortNumber:
+		return d.reuse(uint64(typ - binShortReuse))
+	case typ >= binShortNumber && typ < binShortNumber+20:
+		return uint64(typ - binShortNumber), nil
+	default:
+		return nil, fmt.Errorf("binary protocol: unknown value type %d", typ)
+	}
+}
+
+func (d *binaryDecoder) peek() byte {
+	if d.pos >= len(d.buf) {
+		return 0
+	}
+	return d.buf[d.pos]
+}
+
+func (d *binaryDecoder) byte() (byte, error) {
+	if d.pos >= len(d.buf) {
+		return 0, errBinaryTruncated
+	}
+	b := d.buf[d.pos]
+	d.pos++
+	return b, nil
+}
+
+func (d *binaryDecoder) uint(size int) (uint64, error) {
+	if d.pos+size > len(d.buf) {
+		return 0, errBinaryTruncated
+	}
+	var n uint64
+	for i := size - 1; i >= 0; i-- {
+		n = n<<8 | uint64(d.buf[d.pos+i])
+	}
+	d.pos += size
+	return n, nil
+}
+
+func (d *binaryDecoder) string(n int) (string, error) {
+	if n < 0 || d.pos+n > len(d.buf) {
+		return "", errBinaryTruncated
+	}
+	s := string(d.buf[d.pos : d.pos+n])
+	d.pos += n
+	d.strings = append(d.strings, s)
+	return s, nil
+}
+
+func (d *binaryDecoder) reuse(id uint64) (string, error) {
+	if id >= uint64(len(d.strings)) {
+		return "", fmt.Errorf("binary protocol: unknown string id %d", id)
+	}
+	return d.strings[id], nil
+}
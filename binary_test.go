@@ -0,0 +1,488 @@
+package pcloud
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEncodeBinaryRequest(t *testing.T) {
+	req, err := encodeBinaryRequest("stat", url.Values{"fileid": {"42"}, "auth": {"tok"}}, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte{
+		0, 0, // length, filled in below
+		4, 's', 't', 'a', 't',
+		2,
+		4, 'a', 'u', 't', 'h', 3, 0, 0, 0, 't', 'o', 'k',
+		6, 'f', 'i', 'l', 'e', 'i', 'd', 2, 0, 0, 0, '4', '2',
+	}
+	binary.LittleEndian.PutUint16(want, uint16(len(want)-2))
+	if !bytes.Equal(req, want) {
+		t.Fatalf("got  %v\nwant %v", req, want)
+	}
+}
+
+func TestEncodeBinaryRequestData(t *testing.T) {
+	req, err := encodeBinaryRequest("uploadfile", url.Values{}, 0x0102, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte{0, 0, 10 | 0x80, 0x02, 0x01, 0, 0, 0, 0, 0, 0}
+	want = append(want, "uploadfile"...)
+	want = append(want, 0)
+	binary.LittleEndian.PutUint16(want, uint16(len(want)-2))
+	if !bytes.Equal(req, want) {
+		t.Fatalf("got  %v\nwant %v", req, want)
+	}
+}
+
+func TestEncodeBinaryParam(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  []byte
+	}{
+		{"string", "ab", []byte{1, 'n', 2, 0, 0, 0, 'a', 'b'}},
+		{"empty string", "", []byte{1, 'n', 0, 0, 0, 0}},
+		{"number", uint64(0x0102030405), []byte{1<<6 | 1, 'n', 5, 4, 3, 2, 1, 0, 0, 0}},
+		{"true", true, []byte{2<<6 | 1, 'n', 1}},
+		{"false", false, []byte{2<<6 | 1, 'n', 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := encodeBinaryParam(&b, "n", tt.value); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(b.Bytes(), tt.want) {
+				t.Fatalf("got %v, want %v", b.Bytes(), tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeBinaryRequestErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		params url.Values
+	}{
+		{"empty method", "", nil},
+		{"long method", strings.Repeat("m", 128), nil},
+		{"long param name", "stat", url.Values{strings.Repeat("p", 64): {"1"}}},
+		{"too many params", "stat", url.Values{"p": make([]string, 256)}},
+		{"too large", "stat", url.Values{"p": {strings.Repeat("x", 1<<16)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := encodeBinaryRequest(tt.method, tt.params, 0, false); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestBinaryDecoder(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		want any
+	}{
+		{"short string", []byte{100 + 3, 'a', 'b', 'c'}, "abc"},
+		{"empty string", []byte{100}, ""},
+		{"string 1 byte length", []byte{0, 2, 'h', 'i'}, "hi"},
+		{"string 2 byte length", []byte{1, 2, 0, 'h', 'i'}, "hi"},
+		{"string 3 byte length", []byte{2, 2, 0, 0, 'h', 'i'}, "hi"},
+		{"string 4 byte length", []byte{3, 2, 0, 0, 0, 'h', 'i'}, "hi"},
+		{"short number", []byte{200 + 19}, uint64(19)},
+		{"number 1 byte", []byte{8, 0xff}, uint64(0xff)},
+		{"number 2 bytes", []byte{9, 0x34, 0x12}, uint64(0x1234)},
+		{"number 8 bytes", []byte{15, 8, 7, 6, 5, 4, 3, 2, 1}, uint64(0x0102030405060708)},
+		{"true", []byte{19}, true},
+		{"false", []byte{18}, false},
+		{"empty array", []byte{17, 255}, []any{}},
+		{"array", []byte{17, 200 + 1, 101, 'x', 19, 255}, []any{uint64(1), "x", true}},
+		{"empty hash", []byte{16, 255}, map[string]any{}},
+		{
+			"hash",
+			[]byte{16, 100 + 6, 'r', 'e', 's', 'u', 'l', 't', 200, 100 + 4, 'n', 'a', 'm', 'e', 100 + 1, 'a', 255},
+			map[string]any{"result": uint64(0), "name": "a"},
+		},
+		{
+			"short reuse",
+			[]byte{17, 100 + 1, 'a', 100 + 1, 'b', 150 + 1, 150, 255},
+			[]any{"a", "b", "b", "a"},
+		},
+		{
+			"long reuse",
+			[]byte{17, 0, 1, 'a', 4, 0, 5, 0, 0, 6, 0, 0, 0, 7, 0, 0, 0, 0, 255},
+			[]any{"a", "a", "a", "a", "a"},
+		},
+		{
+			"nested",
+			[]byte{16, 100 + 8, 'c', 'o', 'n', 't', 'e', 'n', 't', 's', 17, 16, 150, 17, 255, 255, 255, 255},
+			map[string]any{"contents": []any{map[string]any{"contents": []any{}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := &binaryDecoder{buf: tt.in}
+			got, err := dec.decode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+			if dec.pos != len(tt.in) {
+				t.Fatalf("consumed %d of %d bytes", dec.pos, len(tt.in))
+			}
+			if dec.dataLen != -1 {
+				t.Fatalf("dataLen = %d, want -1", dec.dataLen)
+			}
+		})
+	}
+}
+
+func TestBinaryDecoderData(t *testing.T) {
+	in := []byte{16, 100 + 4, 'd', 'a', 't', 'a', 20, 5, 0, 0, 0, 0, 0, 0, 0, 255}
+	dec := &binaryDecoder{buf: in}
+	got, err := dec.decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]any{"data": uint64(5)}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+	if dec.dataLen != 5 {
+		t.Fatalf("dataLen = %d, want 5", dec.dataLen)
+	}
+}
+
+func TestBinaryDecoderErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+	}{
+		{"empty", nil},
+		{"truncated string", []byte{100 + 3, 'a'}},
+		{"truncated length", []byte{3, 1, 0}},
+		{"truncated number", []byte{15, 1, 2}},
+		{"unterminated array", []byte{17, 200}},
+		{"unterminated hash", []byte{16, 101, 'k', 200}},
+		{"non-string key", []byte{16, 200, 200, 255}},
+		{"unknown reuse id", []byte{150 + 2}},
+		{"unknown type", []byte{21}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := &binaryDecoder{buf: tt.in}
+			if _, err := dec.decode(); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	values := []any{
+		"",
+		strings.Repeat("s", 49),
+		strings.Repeat("s", 300),
+		strings.Repeat("s", 70000),
+		uint64(0),
+		uint64(19),
+		uint64(20),
+		uint64(1) << 63,
+		true,
+		false,
+		[]any{"a", "a", []any{}, map[string]any{}},
+		map[string]any{"metadata": map[string]any{"name": "x", "size": uint64(1024), "isfolder": false}},
+	}
+	for _, v := range values {
+		var b bytes.Buffer
+		newTestEncoder(&b).value(v)
+		dec := &binaryDecoder{buf: b.Bytes()}
+		got, err := dec.decode()
+		if err != nil {
+			t.Fatalf("%v: %v", v, err)
+		}
+		if !reflect.DeepEqual(got, v) {
+			t.Fatalf("got %#v, want %#v", got, v)
+		}
+	}
+}
+
+func TestBinaryAddr(t *testing.T) {
+	var lookups []string
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		lookups = append(lookups, req.URL.Host+req.URL.Path)
+		switch req.URL.Host {
+		case "eapi.pcloud.com":
+			return jsonResponse(req, `{"result":0,"api":["eapi.pcloud.com"],"binapi":["bineapi.pcloud.com"]}`), nil
+		case "example.com:8":
+			return jsonResponse(req, `{"result":0,"api":["example.com:8"],"binapi":["bin.example.com:9"]}`), nil
+		default:
+			return jsonResponse(req, `{"result":0,"api":[],"binapi":[]}`), nil
+		}
+	})
+
+	tests := map[string]string{
+		BaseURLUS:                     "binapi.pcloud.com:443",
+		"https://api.pcloud.com:8443": "binapi.pcloud.com:8443",
+		BaseURLEU:                     "bineapi.pcloud.com:443",
+		"https://example.com:8":       "bin.example.com:9",
+	}
+	for baseURL, want := range tests {
+		st := NewClient(baseURL,
+			WithHTTPClient(&http.Client{Transport: transport}),
+			WithRateLimit(60000),
+			WithBinaryProtocol(nil),
+		).state()
+		for range 2 {
+			got, err := st.binaryAddr(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("binaryAddr(%q) = %q, want %q", baseURL, got, want)
+			}
+		}
+	}
+	slices.Sort(lookups)
+	if want := []string{"eapi.pcloud.com/getapiserver", "example.com:8/getapiserver"}; !slices.Equal(lookups, want) {
+		t.Errorf("lookups = %v, want %v", lookups, want)
+	}
+
+	st := NewClient("https://other.example.com",
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithRateLimit(60000),
+		WithBinaryProtocol(nil),
+	).state()
+	if _, err := st.binaryAddr(context.Background()); err == nil {
+		t.Error("expected an error without binary api servers")
+	}
+}
+
+func TestBinaryTransport(t *testing.T) {
+	srv := newTestBinaryServer(t, func(method string, params url.Values, data []byte) any {
+		switch method {
+		case "listfolder":
+			if params.Get("auth") != "tok" || params.Get("folderid") != "7" {
+				return map[string]any{"result": uint64(1000), "error": "Log in required."}
+			}
+			return map[string]any{
+				"result": uint64(0),
+				"metadata": map[string]any{
+					"name":     "docs",
+					"isfolder": true,
+					"folderid": uint64(7),
+					"contents": []any{
+						map[string]any{"name": "a.txt", "isfolder": false, "fileid": uint64(9), "size": uint64(3)},
+					},
+				},
+			}
+		case "uploadfile":
+			return map[string]any{
+				"result":  uint64(0),
+				"fileids": []any{uint64(11)},
+				"metadata": []any{map[string]any{
+					"name":   params.Get("filename"),
+					"fileid": uint64(11),
+					"size":   uint64(len(data)),
+				}},
+			}
+		default:
+			return map[string]any{"result": uint64(2005), "error": "Directory does not exist."}
+		}
+	})
+
+	c := NewClient(BaseURLUS,
+		WithAuthToken("tok"),
+		WithRateLimit(60000),
+		WithBinaryProtocol(&BinaryTransport{DialContext: srv.dial}),
+	)
+	ctx := context.Background()
+
+	folder, err := c.ListFolder(ctx, 7, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if folder.Name != "docs" || len(folder.Contents) != 1 || folder.Contents[0].FileID != 9 {
+		t.Fatalf("unexpected folder %+v", folder)
+	}
+
+	meta, err := c.Upload(ctx, 7, "b.txt", strings.NewReader("hello"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Name != "b.txt" || meta.Size != 5 {
+		t.Fatalf("unexpected metadata %+v", meta)
+	}
+
+	_, err = c.ListFolder(ctx, 8, nil)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Result != 1000 {
+		t.Fatalf("got %v, want result 1000", err)
+	}
+
+	if srv.dials != 1 {
+		t.Fatalf("dialed %d times, want connection reuse", srv.dials)
+	}
+}
+
+type testEncoder struct {
+	b       *bytes.Buffer
+	strings map[string]int
+}
+
+func newTestEncoder(b *bytes.Buffer) *testEncoder {
+	return &testEncoder{b: b, strings: map[string]int{}}
+}
+
+func (e *testEncoder) value(v any) {
+	switch v := v.(type) {
+	case string:
+		if id, ok := e.strings[v]; ok {
+			if id < 50 {
+				e.b.WriteByte(byte(binShortReuse + id))
+			} else {
+				e.b.WriteByte(binStringReuse + 3)
+				e.b.Write(binary.LittleEndian.AppendUint32(nil, uint32(id)))
+			}
+			return
+		}
+		e.strings[v] = len(e.strings)
+		if len(v) < 50 {
+			e.b.WriteByte(byte(binShortString + len(v)))
+		} else {
+			e.b.WriteByte(binString + 3)
+			e.b.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(v))))
+		}
+		e.b.WriteString(v)
+	case uint64:
+		if v < 20 {
+			e.b.WriteByte(byte(binShortNumber + v))
+			return
+		}
+		e.b.WriteByte(binNumber + 7)
+		e.b.Write(binary.LittleEndian.AppendUint64(nil, v))
+	case bool:
+		if v {
+			e.b.WriteByte(binTrue)
+		} else {
+			e.b.WriteByte(binFalse)
+		}
+	case []any:
+		e.b.WriteByte(binArray)
+		for _, item := range v {
+			e.value(item)
+		}
+		e.b.WriteByte(binEnd)
+	case map[string]any:
+		e.b.WriteByte(binHash)
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			e.value(key)
+			e.value(v[key])
+		}
+		e.b.WriteByte(binEnd)
+	default:
+		panic("unsupported test value")
+	}
+}
+
+type testBinaryServer struct {
+	t       *testing.T
+	handler func(method string, params url.Values, data []byte) any
+	dials   int
+}
+
+func newTestBinaryServer(t *testing.T, handler func(method string, params url.Values, data []byte) any) *testBinaryServer {
+	return &testBinaryServer{t: t, handler: handler}
+}
+
+func (s *testBinaryServer) dial(_ context.Context, _, _ string) (net.Conn, error) {
+	s.dials++
+	client, server := net.Pipe()
+	s.t.Cleanup(func() { client.Close() })
+	go s.serve(server)
+	return client, nil
+}
+
+func (s *testBinaryServer) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		method, params, data, err := readTestBinaryRequest(conn)
+		if err != nil {
+			return
+		}
+		var body bytes.Buffer
+		newTestEncoder(&body).value(s.handler(method, params, data))
+		resp := binary.LittleEndian.AppendUint32(nil, uint32(body.Len()))
+		if _, err := conn.Write(append(resp, body.Bytes()...)); err != nil {
+			return
+		}
+	}
+}
+
+func readTestBinaryRequest(r io.Reader) (string, url.Values, []byte, error) {
+	var size [2]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return "", nil, nil, err
+	}
+	buf := make([]byte, binary.LittleEndian.Uint16(size[:]))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", nil, nil, err
+	}
+
+	methodLen := int(buf[0] & 0x7f)
+	pos := 1
+	var dataLen uint64
+	hasData := buf[0]&0x80 != 0
+	if hasData {
+		dataLen = binary.LittleEndian.Uint64(buf[pos:])
+		pos += 8
+	}
+	method := string(buf[pos : pos+methodLen])
+	pos += methodLen
+
+	params := url.Values{}
+	count := int(buf[pos])
+	pos++
+	for range count {
+		typ, nameLen := buf[pos]>>6, int(buf[pos]&0x3f)
+		pos++
+		name := string(buf[pos : pos+nameLen])
+		pos += nameLen
+		if typ != binParamString {
+			return "", nil, nil, errors.New("unexpected parameter type")
+		}
+		n := int(binary.LittleEndian.Uint32(buf[pos:]))
+		pos += 4
+		params.Add(name, string(buf[pos:pos+n]))
+		pos += n
+	}
+
+	var data []byte
+	if hasData {
+		data = make([]byte, dataLen)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return "", nil, nil, err
+		}
+	}
+	return method, params, data, nil
+}
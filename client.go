@@ -6,11 +6,14 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/oauth2"
-	"golang.org/x/time/rate"
 )
 
 const (
@@ -20,118 +23,406 @@ const (
 )
 
 type Client struct {
+	mu          sync.RWMutex
+	regionMu    sync.Mutex
 	baseURL     string
 	httpClient  *http.Client
 	auth        string
 	tokenSource oauth2.TokenSource
 	logger      *slog.Logger
-	limiter     *rate.Limiter
+	limiter     *Limiter
+	autoRegion  bool
+	userID      uint64
+	credStore   CredentialStore
+	middleware  []Middleware
+	binary      *BinaryTransport
 }
 
-func NewClient(baseURL string) *Client {
-	if baseURL == "" {
+type Option func(*Client)
+
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = newRedactLogger(logger)
+	}
+}
+
+func WithRateLimit(rpm float64) Option {
+	return func(c *Client) {
+		if rpm > 0 {
+			c.limiter = NewLimiter(rpm, 1)
+		}
+	}
+}
+
+func WithLimiter(l *Limiter) Option {
+	return func(c *Client) {
+		c.limiter = l
+	}
+}
+
+// WithTokenSource authenticates with OAuth tokens from ts, replacing any
+// auth token.
+func WithTokenSource(ts oauth2.TokenSource) Option {
+	return func(c *Client) {
+		c.tokenSource = ts
+		c.auth = ""
+	}
+}
+
+// WithAuthToken authenticates with token, replacing any token source.
+func WithAuthToken(token string) Option {
+	return func(c *Client) {
+		c.auth = token
+		c.tokenSource = nil
+	}
+}
+
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = baseURL
+		c.autoRegion = false
+	}
+}
+
+func NewClient(baseURL string, opts ...Option) *Client {
+	autoRegion := baseURL == ""
+	if autoRegion {
 		baseURL = BaseURLUS
 	}
-	return &Client{
+	c := &Client{
 		baseURL:    baseURL,
 		httpClient: http.DefaultClient,
 		logger:     newNoopLogger(),
-		limiter:    rate.NewLimiter(rate.Limit(DefaultRPM/60.0), 1),
+		limiter:    NewLimiter(DefaultRPM, 1),
+		autoRegion: autoRegion,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// With returns an independent copy of c with opts applied. The copy does not
+// share c's credential store, so logging it in or out leaves c's persisted
+// session alone; call SetCredentialStore on it to persist its own.
+func (c *Client) With(opts ...Option) *Client {
+	clone := c.clone()
+	for _, opt := range opts {
+		opt(clone)
+	}
+	return clone
+}
+
+func (c *Client) clone() *Client {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return &Client{
+		baseURL:     c.baseURL,
+		httpClient:  c.httpClient,
+		auth:        c.auth,
+		tokenSource: c.tokenSource,
+		logger:      c.logger,
+		limiter:     c.limiter,
+		autoRegion:  c.autoRegion,
+		userID:      c.userID,
+		middleware:  c.middleware,
+		binary:      c.binary,
 	}
 }
 
 func (c *Client) SetHTTPClient(client *http.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.httpClient = client
 }
 
 func (c *Client) SetLogger(logger *slog.Logger) {
-	c.logger = logger
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logger = newRedactLogger(logger)
 }
 
 func (c *Client) SetRateLimit(rpm float64) error {
-	if rpm < DefaultRPM {
-		return fmt.Errorf("rate limit %.1f RPM is below minimum %.1f RPM", rpm, DefaultRPM)
+	if rpm <= 0 {
+		return fmt.Errorf("rate limit %.1f RPM must be positive", rpm)
 	}
-	c.limiter = rate.NewLimiter(rate.Limit(rpm/60.0), 1)
+	c.SetLimiter(NewLimiter(rpm, 1))
 	return nil
 }
 
+func (c *Client) SetLimiter(l *Limiter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limiter = l
+}
+
+func (c *Client) Limiter() *Limiter {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.limiter
+}
+
 func (c *Client) SetTokenSource(ts oauth2.TokenSource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.tokenSource = ts
+	c.auth = ""
 }
 
-func (c *Client) do(ctx context.Context, method string, params url.Values, result apiError) error {
-	if err := c.setAuth(params); err != nil {
-		return err
+func (c *Client) withBaseURL(baseURL string) *Client {
+	return c.With(WithBaseURL(baseURL))
+}
+
+type clientState struct {
+	baseURL     string
+	httpClient  *http.Client
+	auth        string
+	tokenSource oauth2.TokenSource
+	logger      *slog.Logger
+	limiter     *Limiter
+	middleware  []Middleware
+	binary      *BinaryTransport
+}
+
+func (c *Client) state() clientState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return clientState{
+		baseURL:     c.baseURL,
+		httpClient:  c.httpClient,
+		auth:        c.auth,
+		tokenSource: c.tokenSource,
+		logger:      c.logger,
+		limiter:     c.limiter,
+		middleware:  c.middleware,
+		binary:      c.binary,
 	}
+}
 
-	c.logger.Debug("request", "method", method)
-	if err := c.limiter.Wait(ctx); err != nil {
-		return err
+func (c *Client) setSession(auth string, userID uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.auth = auth
+	c.userID = userID
+}
+
+func (c *Client) send(ctx context.Context, kind CallKind, method string, params url.Values, body io.Reader, contentType string) (*CallResponse, clientState, error) {
+	if err := c.resolveRegion(ctx); err != nil {
+		return nil, clientState{}, err
+	}
+	st := c.state()
+	if err := st.setAuth(params); err != nil {
+		return nil, st, err
 	}
 
-	reqURL := fmt.Sprintf("%s/%s?%s", c.baseURL, method, params.Encode())
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	call := newCall(method, kind, params)
+	call.body = body
+	call.contentType = contentType
+	if sizer, ok := body.(interface{ Len() int }); ok {
+		call.ContentLength = int64(sizer.Len())
+	}
+	resp, err := st.roundTrip(ctx, call)
+	return resp, st, err
+}
+
+func (st *clientState) roundTrip(ctx context.Context, call *Call) (*CallResponse, error) {
+	resp, err := chain(st.middleware, st.transport)(ctx, call)
+	if err == nil && resp == nil {
+		return nil, fmt.Errorf("%s: middleware returned no response", call.Method)
+	}
+	return resp, err
+}
+
+func (st *clientState) transport(ctx context.Context, call *Call) (*CallResponse, error) {
+	if call.Kind == CallDownload {
+		return st.download(ctx, call)
+	}
+	if st.binary != nil && call.Kind != CallContent {
+		return st.binaryTransport(ctx, call)
+	}
+
+	httpMethod := http.MethodGet
+	body, contentType := call.body, call.contentType
+	if body != nil {
+		httpMethod = http.MethodPost
+	}
+
+	params := call.requestParams()
+	public, sensitive := splitSensitive(params)
+	var form string
+	if len(sensitive) > 0 {
+		if body == nil {
+			httpMethod = http.MethodPost
+			form = sensitive.Encode()
+			contentType = "application/x-www-form-urlencoded"
+		} else if prefix, ok := bodyPrefix(contentType, sensitive); ok {
+			body = io.MultiReader(strings.NewReader(prefix), body)
+		} else {
+			return nil, fmt.Errorf("%s: cannot send credentials in a %q request body", call.Method, contentType)
+		}
+	}
+	reqURL := st.baseURL + "/" + call.Method
+	if len(public) > 0 {
+		reqURL += "?" + public.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		st.logger.Debug("request", "method", call.Method, "attempt", attempt)
+		if err := st.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		reqBody := body
+		if form != "" {
+			reqBody = strings.NewReader(form)
+		}
+		req, err := http.NewRequestWithContext(ctx, httpMethod, reqURL, reqBody)
+		if err != nil {
+			return nil, redactError(err)
+		}
+		maps.Copy(req.Header, call.Header)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		start := time.Now()
+		resp, err := st.httpClient.Do(req)
+		if err != nil {
+			err = redactError(err)
+			st.logger.Error("request failed", "method", call.Method, "error", err)
+			return nil, err
+		}
+		if !isThrottledStatus(resp.StatusCode) {
+			return st.readResponse(call, req, resp, start, attempt)
+		}
+
+		resp.Body.Close()
+		st.limiter.Backoff()
+		st.logger.Warn("throttled", "method", call.Method, "status", resp.StatusCode, "rpm", st.limiter.RPM())
+		if body != nil || attempt >= maxThrottleRetries {
+			return nil, fmt.Errorf("%s: %s", call.Method, resp.Status)
+		}
+	}
+}
+
+func (st *clientState) readResponse(call *Call, req *http.Request, resp *http.Response, start time.Time, retries int) (*CallResponse, error) {
+	out := &CallResponse{
+		HTTPStatus: resp.StatusCode,
+		Host:       req.URL.Host,
+		Retries:    retries,
+	}
+	if call.Kind == CallContent && !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		out.Duration = time.Since(start)
+		out.ContentLength = resp.ContentLength
+		out.Body = resp.Body
+		if resp.StatusCode == http.StatusOK {
+			st.limiter.Success()
+		}
+		return out, nil
+	}
+
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("%s: %w", call.Method, err)
 	}
+	out.Duration = time.Since(start)
+	out.ContentLength = int64(len(raw))
+	out.Raw = raw
 
-	resp, err := c.httpClient.Do(req)
+	var result Error
+	if json.Unmarshal(raw, &result) == nil {
+		out.Result = result.Result
+		st.limiter.observe(out.Result)
+	}
+	return out, nil
+}
+
+func (st *clientState) download(ctx context.Context, call *Call) (*CallResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, call.URL, nil)
 	if err != nil {
-		c.logger.Error("request failed", "method", method, "error", err)
-		return err
+		return nil, redactError(err)
 	}
-	defer resp.Body.Close()
+	maps.Copy(req.Header, call.Header)
 
-	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-		c.logger.Error("decode failed", "method", method, "error", err)
-		return err
+	start := time.Now()
+	resp, err := st.httpClient.Do(req)
+	if err != nil {
+		return nil, redactError(err)
 	}
-	return result.Err()
+	if isThrottledStatus(resp.StatusCode) {
+		st.limiter.Backoff()
+	}
+	return &CallResponse{
+		HTTPStatus:    resp.StatusCode,
+		Host:          req.URL.Host,
+		Duration:      time.Since(start),
+		ContentLength: resp.ContentLength,
+		Body:          resp.Body,
+	}, nil
 }
 
-func (c *Client) doPost(ctx context.Context, method string, params url.Values, body io.Reader, contentType string, result apiError) error {
-	if err := c.setAuth(params); err != nil {
+func (st *clientState) decode(method string, resp *CallResponse, result APIResult) error {
+	if err := json.Unmarshal(resp.Raw, result); err != nil {
+		st.logger.Error("decode failed", "method", method, "error", err)
 		return err
 	}
+	return result.Err()
+}
 
-	c.logger.Debug("request", "method", method)
-	if err := c.limiter.Wait(ctx); err != nil {
+func (c *Client) do(ctx context.Context, method string, params url.Values, result APIResult) error {
+	resp, st, err := c.send(ctx, CallAPI, method, params, nil, "")
+	if err != nil {
 		return err
 	}
+	return st.decode(method, resp, result)
+}
 
-	reqURL := fmt.Sprintf("%s/%s?%s", c.baseURL, method, params.Encode())
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reqURL, body)
+func (c *Client) doPost(ctx context.Context, method string, params url.Values, body io.Reader, contentType string, result APIResult) error {
+	resp, st, err := c.send(ctx, CallUpload, method, params, body, contentType)
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", contentType)
+	return st.decode(method, resp, result)
+}
 
-	resp, err := c.httpClient.Do(req)
+func (c *Client) doContent(ctx context.Context, method string, params url.Values) (io.ReadCloser, error) {
+	resp, st, err := c.send(ctx, CallContent, method, params, nil, "")
 	if err != nil {
-		c.logger.Error("request failed", "method", method, "error", err)
-		return err
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
-		c.logger.Error("decode failed", "method", method, "error", err)
-		return err
+	if resp.Body == nil {
+		var result Error
+		if err := st.decode(method, resp, &result); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: unexpected JSON response", method)
 	}
-	return result.Err()
+	if resp.HTTPStatus != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s failed: %d %s", method, resp.HTTPStatus, http.StatusText(resp.HTTPStatus))
+	}
+	return resp.Body, nil
 }
 
-func (c *Client) setAuth(params url.Values) error {
-	if c.tokenSource != nil {
-		token, err := c.tokenSource.Token()
+func (st *clientState) setAuth(params url.Values) error {
+	if st.tokenSource != nil {
+		token, err := st.tokenSource.Token()
 		if err != nil {
 			return err
 		}
 		params.Set("auth", token.AccessToken)
 		return nil
 	}
-	if c.auth != "" {
-		params.Set("auth", c.auth)
+	if st.auth != "" {
+		params.Set("auth", st.auth)
 	}
 	return nil
 }
@@ -0,0 +1,72 @@
+package pcloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestWithDoesNotShareCredentialStore(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"result":0}`)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	store := NewMemoryCredentialStore()
+	if err := store.Save(ctx, &Credentials{AuthToken: "parent", BaseURL: srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient(srv.URL, WithRateLimit(60000))
+	if err := c.SetCredentialStore(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+
+	other := c.With(WithAuthToken("other"))
+	if err := other.Logout(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	creds, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("parent credentials lost: %v", err)
+	}
+	if creds.AuthToken != "parent" {
+		t.Errorf("stored token = %q, want %q", creds.AuthToken, "parent")
+	}
+}
+
+func TestAuthOptionsReplaceEachOther(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		got = append(got, r.Form.Get("auth"))
+		fmt.Fprint(w, `{"result":0}`)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	oauth := NewClient(srv.URL, WithRateLimit(60000),
+		WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "oauth"})))
+	plain := oauth.With(WithAuthToken("plain"))
+	back := plain.With(WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "oauth2"})))
+
+	for _, c := range []*Client{oauth, plain, back} {
+		if _, err := c.UserInfo(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"oauth", "plain", "oauth2"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("call %d sent auth %q, want %q", i, got[i], want[i])
+		}
+	}
+	if plain.AuthToken() != "plain" || back.AuthToken() != "" {
+		t.Errorf("AuthToken() = %q, %q", plain.AuthToken(), back.AuthToken())
+	}
+}
@@ -0,0 +1,260 @@
+package pcloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+var ErrNoCredentials = errors.New("no stored credentials")
+
+type Credentials struct {
+	AuthToken string `json:"auth"`
+	BaseURL   string `json:"baseurl,omitempty"`
+	UserID    uint64 `json:"userid,omitempty"`
+}
+
+type CredentialStore interface {
+	Load(ctx context.Context) (*Credentials, error)
+	Save(ctx context.Context, creds *Credentials) error
+	Delete(ctx context.Context) error
+}
+
+func (c *Client) AuthToken() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.auth
+}
+
+func (c *Client) SetAuthToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.auth = token
+	c.tokenSource = nil
+}
+
+func (c *Client) UserID() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.userID
+}
+
+func (c *Client) Credentials() *Credentials {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return &Credentials{
+		AuthToken: c.auth,
+		BaseURL:   c.baseURL,
+		UserID:    c.userID,
+	}
+}
+
+func (c *Client) SetCredentials(creds *Credentials) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.auth = creds.AuthToken
+	c.tokenSource = nil
+	c.userID = creds.UserID
+	if creds.BaseURL != "" {
+		c.baseURL = creds.BaseURL
+		c.autoRegion = false
+	}
+}
+
+func (c *Client) SetCredentialStore(ctx context.Context, store CredentialStore) error {
+	c.mu.Lock()
+	c.credStore = store
+	c.mu.Unlock()
+
+	creds, err := store.Load(ctx)
+	if errors.Is(err, ErrNoCredentials) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.SetCredentials(creds)
+	return nil
+}
+
+func (c *Client) credentialStore() CredentialStore {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.credStore
+}
+
+func (c *Client) saveCredentials(ctx context.Context) error {
+	store := c.credentialStore()
+	if store == nil {
+		return nil
+	}
+	if err := store.Save(ctx, c.Credentials()); err != nil {
+		return fmt.Errorf("save credentials: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) deleteCredentials(ctx context.Context) error {
+	store := c.credentialStore()
+	if store == nil {
+		return nil
+	}
+	if err := store.Delete(ctx); err != nil {
+		return fmt.Errorf("delete credentials: %w", err)
+	}
+	return nil
+}
+
+type FileCredentialStore struct {
+	path string
+}
+
+func NewFileCredentialStore(path string) *FileCredentialStore {
+	return &FileCredentialStore{path: path}
+}
+
+func (s *FileCredentialStore) Load(_ context.Context) (*Credentials, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNoCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var creds Credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("%s: %w", s.path, err)
+	}
+	if creds.AuthToken == "" {
+		return nil, ErrNoCredentials
+	}
+	return &creds, nil
+}
+
+func (s *FileCredentialStore) Save(_ context.Context, creds *Credentials) error {
+	data, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".pcloud-credentials-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *FileCredentialStore) Delete(_ context.Context) error {
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+type EnvCredentialStore struct {
+	prefix string
+}
+
+func NewEnvCredentialStore(prefix string) *EnvCredentialStore {
+	if prefix == "" {
+		prefix = "PCLOUD_"
+	}
+	return &EnvCredentialStore{prefix: prefix}
+}
+
+func (s *EnvCredentialStore) Load(_ context.Context) (*Credentials, error) {
+	token := os.Getenv(s.prefix + "AUTH_TOKEN")
+	if token == "" {
+		return nil, ErrNoCredentials
+	}
+
+	creds := &Credentials{
+		AuthToken: token,
+		BaseURL:   os.Getenv(s.prefix + "BASE_URL"),
+	}
+	if v := os.Getenv(s.prefix + "USER_ID"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%sUSER_ID: %w", s.prefix, err)
+		}
+		creds.UserID = id
+	}
+	return creds, nil
+}
+
+func (s *EnvCredentialStore) Save(_ context.Context, creds *Credentials) error {
+	if err := os.Setenv(s.prefix+"AUTH_TOKEN", creds.AuthToken); err != nil {
+		return err
+	}
+	if err := os.Setenv(s.prefix+"BASE_URL", creds.BaseURL); err != nil {
+		return err
+	}
+	return os.Setenv(s.prefix+"USER_ID", strconv.FormatUint(creds.UserID, 10))
+}
+
+func (s *EnvCredentialStore) Delete(_ context.Context) error {
+	for _, name := range []string{"AUTH_TOKEN", "BASE_URL", "USER_ID"} {
+		if err := os.Unsetenv(s.prefix + name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type MemoryCredentialStore struct {
+	mu    sync.Mutex
+	creds *Credentials
+}
+
+func NewMemoryCredentialStore() *MemoryCredentialStore {
+	return &MemoryCredentialStore{}
+}
+
+func (s *MemoryCredentialStore) Load(_ context.Context) (*Credentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.creds == nil {
+		return nil, ErrNoCredentials
+	}
+	creds := *s.creds
+	return &creds, nil
+}
+
+func (s *MemoryCredentialStore) Save(_ context.Context, creds *Credentials) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	saved := *creds
+	s.creds = &saved
+	return nil
+}
+
+func (s *MemoryCredentialStore) Delete(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.creds = nil
+	return nil
+}
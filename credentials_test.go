@@ -0,0 +1,133 @@
+package pcloud
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func testCredentialStore(t *testing.T, store CredentialStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := store.Load(ctx); !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("Load() on empty store = %v, want ErrNoCredentials", err)
+	}
+
+	want := Credentials{AuthToken: "token", BaseURL: BaseURLEU, UserID: 42}
+	if err := store.Save(ctx, &want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != want {
+		t.Errorf("Load() = %+v, want %+v", *got, want)
+	}
+
+	if err := store.Delete(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(ctx); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Load() after Delete = %v, want ErrNoCredentials", err)
+	}
+	if err := store.Delete(ctx); err != nil {
+		t.Errorf("second Delete() = %v", err)
+	}
+}
+
+func TestFileCredentialStore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config", "credentials.json")
+	store := NewFileCredentialStore(path)
+	testCredentialStore(t, store)
+
+	ctx := context.Background()
+	if err := store.Save(ctx, &Credentials{AuthToken: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(ctx, &Credentials{AuthToken: "second"}); err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := info.Mode().Perm(); mode != 0o600 {
+			t.Errorf("file mode = %o, want 600", mode)
+		}
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+	if creds, err := store.Load(ctx); err != nil || creds.AuthToken != "second" {
+		t.Errorf("Load() = %+v, %v", creds, err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"auth":""}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(ctx); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Load() with empty token = %v, want ErrNoCredentials", err)
+	}
+	if err := os.WriteFile(path, []byte(`{`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load(ctx); err == nil || errors.Is(err, ErrNoCredentials) {
+		t.Errorf("Load() with corrupt file = %v, want a decode error", err)
+	}
+}
+
+func TestEnvCredentialStore(t *testing.T) {
+	for _, name := range []string{"AUTH_TOKEN", "BASE_URL", "USER_ID"} {
+		t.Setenv("PCLOUDTEST_"+name, "")
+	}
+	store := NewEnvCredentialStore("PCLOUDTEST_")
+	testCredentialStore(t, store)
+
+	t.Setenv("PCLOUDTEST_AUTH_TOKEN", "token")
+	t.Setenv("PCLOUDTEST_USER_ID", "not-a-number")
+	if _, err := store.Load(context.Background()); err == nil {
+		t.Error("Load() accepted an invalid USER_ID")
+	}
+}
+
+func TestMemoryCredentialStore(t *testing.T) {
+	testCredentialStore(t, NewMemoryCredentialStore())
+}
+
+func TestSetCredentialStoreReplacesTokenSource(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryCredentialStore()
+	if err := store.Save(ctx, &Credentials{AuthToken: "stored", BaseURL: BaseURLEU, UserID: 7}); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClient("", WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "oauth"})))
+	if err := c.SetCredentialStore(ctx, store); err != nil {
+		t.Fatal(err)
+	}
+
+	params := make(map[string][]string)
+	st := c.state()
+	if err := st.setAuth(params); err != nil {
+		t.Fatal(err)
+	}
+	if got := params["auth"]; len(got) != 1 || got[0] != "stored" {
+		t.Errorf("auth = %q, want the stored token", got)
+	}
+	if c.BaseURL() != BaseURLEU || c.UserID() != 7 {
+		t.Errorf("BaseURL() = %q, UserID() = %d", c.BaseURL(), c.UserID())
+	}
+}
@@ -9,12 +9,114 @@
 //	err := c.Login(ctx, "user@example.com", "password")
 //	defer c.Logout(ctx)
 //
+// A client created with an empty base URL detects the account's region on
+// login or on its first authenticated call and stays pinned to it:
+//
+//	c := pcloud.NewClient("")
+//	err := c.Login(ctx, "user@example.com", "password")
+//	fmt.Println(c.BaseURL())  // https://eapi.pcloud.com for EU accounts
+//
+// Login uses pCloud's digest scheme, so the plaintext password never leaves
+// the process. Sending the password itself must be requested explicitly:
+//
+//	err := c.LoginWithOpts(ctx, "user@example.com", "password", &pcloud.LoginOpts{PlainPassword: true})
+//
+// Accounts with two-factor authentication supply the second factor through
+// a callback; without one, Login returns a *TwoFactorRequiredError whose token
+// can be passed to LoginTwoFactor:
+//
+//	err := c.LoginWithOpts(ctx, "user@example.com", "password", &pcloud.LoginOpts{
+//	    TwoFactor: func(ctx context.Context, token string) (*pcloud.TwoFactorCode, error) {
+//	        return &pcloud.TwoFactorCode{Code: readCode(), TrustDevice: true}, nil
+//	    },
+//	})
+//
+// Persist the session so later runs skip the login. The store is loaded
+// immediately and updated on every Login and Logout:
+//
+//	store := pcloud.NewFileCredentialStore(filepath.Join(home, ".config", "pcloud", "credentials.json"))
+//	c.SetCredentialStore(ctx, store)
+//	if c.AuthToken() == "" {
+//	    c.Login(ctx, "user@example.com", "password")
+//	}
+//
+// Review and revoke the account's active sessions:
+//
+//	tokens, _ := c.ListTokens(ctx)
+//	c.RevokeToken(ctx, tokens[0].TokenID)
+//	revoked, _ := c.RevokeAllOtherTokens(ctx)
+//
 // Alternatively, use an OAuth2 token:
 //
 //	ctx := context.Background()
 //	c := pcloud.NewClient(pcloud.BaseURLUS)
 //	c.SetTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"}))
 //
+// Authorize runs the whole authorization-code flow on a loopback listener and
+// reports the API host for the account's region:
+//
+//	cfg := &oauth2.Config{ClientID: "id", ClientSecret: "secret", Endpoint: pcloud.Endpoint}
+//	res, _ := pcloud.Authorize(ctx, cfg, func(u string) error {
+//	    fmt.Println("Open", u)
+//	    return nil
+//	})
+//	c := pcloud.NewClient(res.BaseURL)
+//	c.SetTokenSource(oauth2.StaticTokenSource(res.Token))
+//
+// # Configuration
+//
+// Configure the client with options at construction time. A Client is safe
+// for concurrent use; With derives an independent copy, for example to run a
+// batch job with another account or a tighter rate limit:
+//
+//	c := pcloud.NewClient(pcloud.BaseURLEU,
+//	    pcloud.WithHTTPClient(&http.Client{Timeout: time.Minute}),
+//	    pcloud.WithRateLimit(300),
+//	    pcloud.WithLogger(slog.Default()),
+//	)
+//	batch := c.With(pcloud.WithAuthToken(otherToken), pcloud.WithRateLimit(30))
+//
+// Clients working on the same account can share one Limiter. It slows down
+// when pCloud signals throttling (HTTP 429/503 or a rate-limit result code)
+// and gradually recovers to its configured rate afterwards:
+//
+//	limiter := pcloud.NewLimiter(60, 5)  // 60 requests per minute, bursts of 5
+//	a := pcloud.NewClient(pcloud.BaseURLUS, pcloud.WithLimiter(limiter))
+//	b := pcloud.NewClient(pcloud.BaseURLUS, pcloud.WithLimiter(limiter))
+//
+// API calls can use pCloud's binary protocol instead of JSON over HTTPS,
+// which is more compact for metadata-heavy calls such as recursive folder
+// listings. Every method works the same over either protocol; file
+// downloads always use HTTPS:
+//
+//	c := pcloud.NewClient(pcloud.BaseURLEU, pcloud.WithBinaryProtocol(nil))
+//
+// Credentials such as auth tokens, passwords and client secrets are sent in
+// the request body rather than the URL, and are scrubbed from returned errors
+// and from everything written to the configured logger.
+//
+// # Middleware
+//
+// Every API call and file download passes through a chain of Middleware.
+// Each one sees the method name, the parameters with credentials redacted,
+// and on the way back the HTTP status, pCloud result code and duration. It
+// can change the call, or answer it without reaching the network:
+//
+//	timing := func(next pcloud.Handler) pcloud.Handler {
+//	    return func(ctx context.Context, call *pcloud.Call) (*pcloud.CallResponse, error) {
+//	        resp, err := next(ctx, call)
+//	        if err == nil {
+//	            log.Printf("%s result=%d in %s", call.Method, resp.Result, resp.Duration)
+//	        }
+//	        return resp, err
+//	    }
+//	}
+//	c := pcloud.NewClient(pcloud.BaseURLUS, pcloud.WithMiddleware(timing))
+//
+// Middleware registered first runs outermost. The separate
+// github.com/yanmhlv/pcloud/otel module provides OpenTelemetry tracing and
+// metrics as a Middleware.
+//
 // # Folders
 //
 // List, create, rename, copy, and delete folders:
@@ -35,6 +137,18 @@
 //	c.RenameFile(ctx, fileID, "new-name.txt")
 //	c.DeleteFile(ctx, fileID)
 //
+// # Items
+//
+// An Item refers to a file or folder by ID, or to either by path, so one set
+// of methods covers both kinds. ParseItem reads Metadata.ID values such as
+// "f123" and "d456", and the generic methods pick the file or folder
+// endpoint on their own:
+//
+//	item, _ := pcloud.ParseItem(meta.ID)
+//	c.MoveItem(ctx, item, pcloud.PathItem("/archive"), "")
+//	c.RenameItem(ctx, pcloud.PathItem("/photos/old"), "new")
+//	c.RemoveItem(ctx, pcloud.FileItem(fileID))
+//
 // # Streaming
 //
 // Get direct download links for files:
@@ -43,14 +157,80 @@
 //	link, _ := c.GetFileLink(ctx, fileID)
 //	url := link.URL()
 //
+// # Public links
+//
+// Consume content shared through a public link code, no credentials needed:
+//
+//	ctx := context.Background()
+//	anon := pcloud.NewClient(pcloud.BaseURLUS)
+//	for item, err := range anon.WalkPublicLink(ctx, code) {
+//	    // ...
+//	}
+//	body, _ := anon.DownloadPublicLink(ctx, code, fileID, nil)
+//	zip, _ := anon.DownloadPublicLinkZip(ctx, code, nil)
+//
+// Protect a link with a password, later lift its limits, and read its statistics:
+//
+//	link, _ := c.CreateFilePublicLink(ctx, fileID, &pcloud.PublicLinkOpts{Password: "secret"})
+//	c.ChangePublicLink(ctx, link.LinkID, &pcloud.PublicLinkOpts{ClearPassword: true, ClearExpire: true})
+//	stats, _ := c.GetPublicLinkStats(ctx, link.LinkID, nil)
+//
+// Share a selection of files and folders under a single link:
+//
+//	link, _ := c.CreateTreePublicLink(ctx, "handout", []uint64{folderID}, []uint64{fileID}, nil)
+//
+// Import shared files into your own account:
+//
+//	c.CopyPublicFile(ctx, code, fileID, folderID, "")
+//	c.SavePublicLinkZip(ctx, code, folderID, "shared.zip", nil)
+//
+// # Upload links
+//
+// Collect files from people without a pCloud account:
+//
+//	ctx := context.Background()
+//	link, _ := c.CreateUploadLink(ctx, folderID, "Send me your photos", nil)
+//	fmt.Println(link.Link)
+//
+//	anon := pcloud.NewClient(pcloud.BaseURLUS)
+//	anon.UploadToLink(ctx, link.Code, "Alice", "photo.jpg", reader, nil)
+//
 // # Revisions
 //
-// List and revert file revisions:
+// List, read, diff, and revert file revisions:
 //
 //	ctx := context.Background()
 //	revisions, _ := c.ListRevisions(ctx, fileID)
+//	body, _ := c.DownloadRevision(ctx, fileID, revisionID, nil)
+//	diff, _ := c.RevisionDiff(ctx, fileID, oldRevisionID, newRevisionID)
 //	c.RevertRevision(ctx, fileID, revisionID)
 //
+// Restore a whole folder tree to a point in time, previewing the plan first:
+//
+//	ctx := context.Background()
+//	plan, _ := c.RestoreFolderToTime(ctx, folderID, t, &pcloud.RestoreOpts{DryRun: true})
+//	fmt.Print(plan)
+//	report, _ := c.RestoreFolderToTime(ctx, folderID, t, nil)
+//
+// Files and revisions carry pCloud's content hash, so changes can be
+// detected without downloading anything:
+//
+//	if current.ContentChanged(previous) { ... }
+//	if revision.Hash == current.Hash { ... }
+//
+// # Media
+//
+// Metadata.Category tells images, videos, audio, documents and archives
+// apart. For media files the Image, Video and Audio fields carry the
+// properties pCloud extracted, such as dimensions, duration, codecs and
+// tags:
+//
+//	for item, _ := range c.Walk(ctx, 0) {
+//	    if item.Video != nil {
+//	        fmt.Println(item.Name, item.Video.Width, item.Video.Height, item.Video.Duration)
+//	    }
+//	}
+//
 // # Walking
 //
 // Recursively iterate over all files and folders using iter.Seq2:
@@ -62,4 +242,33 @@
 //	    }
 //	    fmt.Println(item.Path)
 //	}
+//
+// # Other API methods
+//
+// Methods without a wrapper can be called directly. Define a response type
+// embedding Error and let CallJSON decode into it; Call and CallPost accept
+// any value implementing APIResult:
+//
+//	type fileHistory struct {
+//	    pcloud.Error
+//	    Entries []struct {
+//	        Event string `json:"event"`
+//	    } `json:"entries"`
+//	}
+//	h, err := pcloud.CallJSON[fileHistory](ctx, c, "getfilehistory", url.Values{"fileid": {"123"}})
+//
+// The manifest package snapshots a folder tree to JSON Lines or CSV and diffs
+// two snapshots into added, removed, modified and moved items. Metadata
+// marshals its times in the API format, so JSON written by this package can
+// always be unmarshalled again.
+//
+// # Testing
+//
+// The pcloudtest/recorder package records real API interactions into
+// cassette files with credentials scrubbed and replays them offline. Plug it
+// in as the client's HTTP transport:
+//
+//	rec, _ := recorder.New("testdata/walk.json", recorder.ModeAuto)
+//	defer rec.Stop()
+//	c := pcloud.NewClient(pcloud.BaseURLUS, pcloud.WithHTTPClient(rec.Client()))
 package pcloud
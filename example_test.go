@@ -1,14 +1,23 @@
 package pcloud_test
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/yanmhlv/pcloud"
+	"golang.org/x/oauth2"
 )
 
 func Example() {
@@ -263,3 +272,568 @@ func ExampleClient_RevertRevision() {
 
 	fmt.Printf("Reverted to revision, new size: %d\n", meta.Size)
 }
+
+func ExampleClient_DownloadRevision() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	revisions, err := c.ListRevisions(ctx, 12345)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(revisions) == 0 {
+		log.Fatal("no previous revisions")
+	}
+
+	body, err := c.DownloadRevision(ctx, 12345, revisions[0].RevisionID, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer body.Close()
+
+	content, err := io.ReadAll(body)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Revision %d: %d bytes\n", revisions[0].RevisionID, len(content))
+}
+
+func ExampleClient_RevisionDiff() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	revisions, err := c.ListRevisions(ctx, 12345)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(revisions) < 2 {
+		log.Fatal("not enough revisions")
+	}
+
+	diff, err := c.RevisionDiff(ctx, 12345, revisions[1].RevisionID, revisions[0].RevisionID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Print(diff)
+}
+
+func ExampleClient_RestoreFolderToTime() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	t := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	plan, err := c.RestoreFolderToTime(ctx, 12345, t, &pcloud.RestoreOpts{DryRun: true})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(plan)
+
+	report, err := c.RestoreFolderToTime(ctx, 12345, t, &pcloud.RestoreOpts{
+		TargetFolderID: 67890,
+		Concurrency:    8,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("copied %d files, %d failed\n", report.Copied, report.Failed)
+}
+
+func ExampleClient_CreateUploadLink() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	link, err := c.CreateUploadLink(ctx, 12345, "Quarterly reports", &pcloud.UploadLinkOpts{
+		ExpireTime: time.Now().Add(7 * 24 * time.Hour).Unix(),
+		MaxFiles:   20,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Share this link: %s\n", link.Link)
+}
+
+func ExampleClient_UploadToLink() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+
+	content := bytes.NewReader([]byte("report contents"))
+	if err := c.UploadToLink(ctx, "link-code", "Alice", "report.txt", content, nil); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("uploaded")
+}
+
+func ExampleClient_WalkPublicLink() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+
+	const code = "link-code"
+	for item, err := range c.WalkPublicLink(ctx, code) {
+		if err != nil {
+			log.Fatal(err)
+		}
+		if item.IsFolder {
+			continue
+		}
+
+		body, err := c.DownloadPublicLink(ctx, code, item.FileID, nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		n, _ := io.Copy(io.Discard, body)
+		body.Close()
+
+		fmt.Printf("%s: %d bytes\n", item.Name, n)
+	}
+}
+
+func ExampleClient_CreateTreePublicLink() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	link, err := c.CreateTreePublicLink(ctx, "project-handout",
+		[]uint64{111, 222}, []uint64{333}, &pcloud.PublicLinkOpts{ShortLink: true})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Share this link: %s\n", link.Link)
+}
+
+func ExampleClient_ListPublicLinks() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	links, err := c.ListPublicLinks(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, link := range links {
+		fmt.Printf("[%s] %s -> %s\n", link.Kind, link.Link, link.Metadata.Name)
+	}
+}
+
+func ExampleClient_ChangePublicLink() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	link, err := c.CreateFilePublicLink(ctx, 12345, &pcloud.PublicLinkOpts{
+		Password:   "s3cret",
+		ExpireTime: time.Now().Add(24 * time.Hour).Unix(),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	link, err = c.ChangePublicLink(ctx, link.LinkID, &pcloud.PublicLinkOpts{
+		ClearPassword: true,
+		ClearExpire:   true,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("password protected: %v\n", link.HasPassword)
+}
+
+func ExampleClient_GetPublicLinkStats() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	stats, err := c.GetPublicLinkStats(ctx, 12345, &pcloud.PublicLinkStatsOpts{
+		From: time.Now().AddDate(0, -1, 0),
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, stat := range stats.Stats {
+		fmt.Printf("%s: %d downloads, %d bytes\n", stat.Date.Format(time.DateOnly), stat.Downloads, stat.Traffic)
+	}
+}
+
+func ExampleClient_LoginWithOpts_twoFactor() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+
+	err := c.LoginWithOpts(ctx, "user@example.com", "password", &pcloud.LoginOpts{
+		TwoFactor: func(ctx context.Context, token string) (*pcloud.TwoFactorCode, error) {
+			fmt.Print("Authentication code: ")
+			code, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil {
+				return nil, err
+			}
+			return &pcloud.TwoFactorCode{Code: strings.TrimSpace(code), TrustDevice: true}, nil
+		},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("logged in successfully")
+}
+
+func ExampleClient_LoginTwoFactor() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+
+	err := c.Login(ctx, "user@example.com", "password")
+	var tfa *pcloud.TwoFactorRequiredError
+	if errors.As(err, &tfa) {
+		if err := c.SendTwoFactorSMS(ctx, tfa.Token); err != nil {
+			log.Fatal(err)
+		}
+		err = c.LoginTwoFactor(ctx, tfa.Token, &pcloud.TwoFactorCode{Code: "123456"})
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("logged in successfully")
+}
+
+func ExampleAuthorize() {
+	ctx := context.Background()
+	cfg := &oauth2.Config{
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		Endpoint:     pcloud.Endpoint,
+	}
+
+	res, err := pcloud.Authorize(ctx, cfg, func(authURL string) error {
+		fmt.Println("Open this URL in your browser:", authURL)
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	c := pcloud.NewClient(res.BaseURL)
+	c.SetTokenSource(oauth2.StaticTokenSource(res.Token))
+
+	fmt.Printf("authorized user %d\n", res.UserID)
+}
+
+func ExampleClient_UseNearestAPIServer() {
+	ctx := context.Background()
+	c := pcloud.NewClient("")
+	if err := c.Login(ctx, "user@example.com", "password"); err != nil {
+		log.Fatal(err)
+	}
+	defer c.Logout(ctx)
+
+	if err := c.UseNearestAPIServer(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("using", c.BaseURL())
+}
+
+func ExampleClient_SetCredentialStore() {
+	ctx := context.Background()
+	c := pcloud.NewClient("")
+
+	store := pcloud.NewFileCredentialStore("/home/user/.config/pcloud/credentials.json")
+	if err := c.SetCredentialStore(ctx, store); err != nil {
+		log.Fatal(err)
+	}
+
+	if c.AuthToken() == "" {
+		if err := c.Login(ctx, "user@example.com", "password"); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	fmt.Printf("user %d on %s\n", c.UserID(), c.BaseURL())
+}
+
+func ExampleClient_ListTokens() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	tokens, err := c.ListTokens(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, token := range tokens {
+		fmt.Printf("%d %s created %s current=%v\n", token.TokenID, token.Device, token.Created, token.IsCurrent)
+	}
+}
+
+func ExampleClient_RevokeAllOtherTokens() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	revoked, err := c.RevokeAllOtherTokens(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("revoked %d sessions\n", revoked)
+}
+
+func ExampleNewClient_options() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLEU,
+		pcloud.WithHTTPClient(&http.Client{Timeout: time.Minute}),
+		pcloud.WithRateLimit(300),
+		pcloud.WithLogger(slog.Default()),
+	)
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	info, err := c.UserInfo(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(info.Email)
+}
+
+func ExampleClient_With() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	slow := c.With(pcloud.WithRateLimit(30))
+	for item, err := range slow.Walk(ctx, 0) {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(item.Name)
+	}
+}
+
+func ExampleNewLimiter() {
+	ctx := context.Background()
+	limiter := pcloud.NewLimiter(30, 3)
+
+	uploader := pcloud.NewClient(pcloud.BaseURLUS, pcloud.WithLimiter(limiter))
+	uploader.Login(ctx, "user@example.com", "password")
+	defer uploader.Logout(ctx)
+
+	indexer := uploader.With(pcloud.WithLimiter(limiter))
+	for item, err := range indexer.Walk(ctx, 0) {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(item.Name)
+	}
+
+	fmt.Printf("current rate: %.0f RPM\n", limiter.RPM())
+}
+
+func ExampleWithMiddleware() {
+	ctx := context.Background()
+	logCalls := func(next pcloud.Handler) pcloud.Handler {
+		return func(ctx context.Context, call *pcloud.Call) (*pcloud.CallResponse, error) {
+			resp, err := next(ctx, call)
+			if err != nil {
+				log.Printf("%s %s: %v", call.Method, call.Params.Encode(), err)
+				return nil, err
+			}
+			log.Printf("%s %s: status=%d result=%d took=%s", call.Method, call.Params.Encode(), resp.HTTPStatus, resp.Result, resp.Duration)
+			return resp, nil
+		}
+	}
+	tagRequests := func(next pcloud.Handler) pcloud.Handler {
+		return func(ctx context.Context, call *pcloud.Call) (*pcloud.CallResponse, error) {
+			call.Header.Set("User-Agent", "backup-job/1.0")
+			return next(ctx, call)
+		}
+	}
+
+	c := pcloud.NewClient(pcloud.BaseURLUS, pcloud.WithMiddleware(logCalls, tagRequests))
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	folder, err := c.ListFolder(ctx, 0, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(folder.Name)
+}
+
+func ExampleCallJSON() {
+	type fileHistory struct {
+		pcloud.Error
+		Entries []struct {
+			Event string      `json:"event"`
+			Time  pcloud.Time `json:"time"`
+		} `json:"entries"`
+	}
+
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	history, err := pcloud.CallJSON[fileHistory](ctx, c, "getfilehistory", url.Values{"fileid": {"123456"}})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, entry := range history.Entries {
+		fmt.Println(entry.Time.Format(time.DateTime), entry.Event)
+	}
+}
+
+func ExampleClient_CallPost() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "notes.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	io.WriteString(part, "hello")
+	writer.Close()
+
+	var resp struct {
+		pcloud.Error
+		FileIDs []uint64 `json:"fileids"`
+	}
+	params := url.Values{"path": {"/inbox"}, "nopartial": {"1"}}
+	if err := c.CallPost(ctx, "uploadfile", params, &body, writer.FormDataContentType(), &resp); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(resp.FileIDs)
+}
+
+func ExampleWithBinaryProtocol() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS, pcloud.WithBinaryProtocol(nil))
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	count := 0
+	for _, err := range c.Walk(ctx, 0) {
+		if err != nil {
+			log.Fatal(err)
+		}
+		count++
+	}
+
+	fmt.Printf("%d items\n", count)
+}
+
+func ExampleMetadata_ContentChanged() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	before, err := c.StatByPath(ctx, "/reports/latest.csv")
+	if err != nil {
+		log.Fatal(err)
+	}
+	time.Sleep(time.Minute)
+	after, err := c.StatByPath(ctx, "/reports/latest.csv")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if after.ContentChanged(before) {
+		fmt.Printf("content changed: %s -> %s\n", before.Hash, after.Hash)
+	}
+
+	revisions, err := c.ListRevisions(ctx, after.FileID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, rev := range revisions {
+		if rev.Hash == after.Hash {
+			fmt.Println("same content as revision", rev.RevisionID)
+		}
+	}
+}
+
+func ExampleMetadata_media() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	for item, err := range c.Walk(ctx, 0) {
+		if err != nil {
+			log.Fatal(err)
+		}
+		switch item.Category {
+		case pcloud.CategoryImage:
+			if item.Image != nil {
+				fmt.Printf("%s: %dx%d\n", item.Path, item.Image.Width, item.Image.Height)
+			}
+		case pcloud.CategoryVideo:
+			if item.Video != nil {
+				fmt.Printf("%s: %dx%d, %s, %s\n", item.Path, item.Video.Width, item.Video.Height, item.Video.Duration, item.Video.VideoCodec)
+			}
+		case pcloud.CategoryAudio:
+			if item.Audio != nil {
+				fmt.Printf("%s: %s - %s (%s)\n", item.Path, item.Audio.Artist, item.Audio.Title, item.Audio.Album)
+			}
+		}
+	}
+}
+
+func ExampleParseItem() {
+	item, err := pcloud.ParseItem("d123")
+	if err != nil {
+		log.Fatal(err)
+	}
+	id, ok := item.FolderID()
+	fmt.Println(item, id, ok)
+	// Output: d123 123 true
+}
+
+func ExampleClient_MoveItem() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	folder, err := c.ListFolderItem(ctx, pcloud.PathItem("/inbox"), nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Files and folders alike go to the archive.
+	for _, entry := range folder.Contents {
+		if _, err := c.MoveItem(ctx, entry.Item(), pcloud.PathItem("/archive"), ""); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -101,6 +101,22 @@ func applyUploadOpts(params url.Values, opts *UploadOpts) {
 func (c *Client) upload(ctx context.Context, params url.Values, filename string, content io.Reader, opts *UploadOpts) (*Metadata, error) {
 	applyUploadOpts(params, opts)
 
+	body, contentType, err := multipartBody(filename, content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp uploadResponse
+	if err := c.doPost(ctx, "uploadfile", params, body, contentType, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Metadata) == 0 {
+		return nil, errors.New("no metadata in response")
+	}
+	return &resp.Metadata[0], nil
+}
+
+func multipartBody(filename string, content io.Reader, opts *UploadOpts) (*bytes.Buffer, string, error) {
 	var contentSize int64 = -1
 	if sizer, ok := content.(interface{ Len() int }); ok {
 		contentSize = int64(sizer.Len())
@@ -109,7 +125,7 @@ func (c *Client) upload(ctx context.Context, params url.Values, filename string,
 	if contentSize < 0 {
 		size, err := getContentSize(content)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 		contentSize = size
 	}
@@ -128,23 +144,15 @@ func (c *Client) upload(ctx context.Context, params url.Values, filename string,
 	writer := multipart.NewWriter(&body)
 	part, err := writer.CreateFormFile("file", filename)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	if _, err := io.Copy(part, readContent); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	if err := writer.Close(); err != nil {
-		return nil, err
-	}
-
-	var resp uploadResponse
-	if err := c.doPost(ctx, "uploadfile", params, &body, writer.FormDataContentType(), &resp); err != nil {
-		return nil, err
-	}
-	if len(resp.Metadata) == 0 {
-		return nil, errors.New("no metadata in response")
+		return nil, "", err
 	}
-	return &resp.Metadata[0], nil
+	return &body, writer.FormDataContentType(), nil
 }
 
 func (c *Client) Upload(ctx context.Context, folderID uint64, filename string, content io.Reader, opts *UploadOpts) (*Metadata, error) {
@@ -180,18 +188,22 @@ func (c *Client) DownloadByPath(ctx context.Context, path string, opts *Download
 }
 
 func (c *Client) downloadFromLink(ctx context.Context, link *FileLink, opts *DownloadOpts) (io.ReadCloser, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link.URL(), nil)
-	if err != nil {
-		return nil, err
-	}
+	call := newCall("download", CallDownload, url.Values{})
+	call.URL = link.URL()
 
-	resp, err := c.httpClient.Do(req)
+	st := c.state()
+	resp, err := st.roundTrip(ctx, call)
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
-		resp.Body.Close()
-		return nil, fmt.Errorf("download failed: %s", resp.Status)
+	if resp.HTTPStatus != http.StatusOK {
+		if resp.Body != nil {
+			resp.Body.Close()
+		}
+		return nil, fmt.Errorf("download failed: %d %s", resp.HTTPStatus, http.StatusText(resp.HTTPStatus))
+	}
+	if resp.Body == nil {
+		return nil, errors.New("download failed: no response body")
 	}
 
 	if opts != nil && opts.OnProgress != nil {
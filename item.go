@@ -0,0 +1,287 @@
+package pcloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type itemKind int
+
+const (
+	itemNone itemKind = iota
+	itemFile
+	itemFolder
+	itemPath
+)
+
+// Item refers to a file or folder by ID, or to either by path. Its string
+// form matches Metadata.ID ("f123" for files, "d456" for folders) or is the
+// path itself, and ParseItem reads it back.
+type Item struct {
+	kind itemKind
+	id   uint64
+	path string
+}
+
+func FileItem(fileID uint64) Item {
+	return Item{kind: itemFile, id: fileID}
+}
+
+func FolderItem(folderID uint64) Item {
+	return Item{kind: itemFolder, id: folderID}
+}
+
+// PathItem refers to the file or folder at path. Operations that behave
+// differently for files and folders look the path up first.
+func PathItem(path string) Item {
+	return Item{kind: itemPath, path: path}
+}
+
+// ParseItem parses a Metadata.ID such as "f123" or "d456", or an absolute
+// path.
+func ParseItem(s string) (Item, error) {
+	if strings.HasPrefix(s, "/") {
+		return PathItem(s), nil
+	}
+	if len(s) < 2 {
+		return Item{}, fmt.Errorf("invalid item %q", s)
+	}
+	id, err := strconv.ParseUint(s[1:], 10, 64)
+	if err != nil {
+		return Item{}, fmt.Errorf("invalid item %q", s)
+	}
+	switch s[0] {
+	case 'f':
+		return FileItem(id), nil
+	case 'd':
+		return FolderItem(id), nil
+	default:
+		return Item{}, fmt.Errorf("invalid item %q", s)
+	}
+}
+
+// Item returns a reference to m by ID.
+func (m *Metadata) Item() Item {
+	if m.IsFolder {
+		return FolderItem(m.FolderID)
+	}
+	return FileItem(m.FileID)
+}
+
+func (i Item) String() string {
+	switch i.kind {
+	case itemFile:
+		return "f" + strconv.FormatUint(i.id, 10)
+	case itemFolder:
+		return "d" + strconv.FormatUint(i.id, 10)
+	case itemPath:
+		return i.path
+	default:
+		return ""
+	}
+}
+
+func (i Item) IsZero() bool {
+	return i.kind == itemNone
+}
+
+// FileID returns the file ID and whether i refers to a file by ID.
+func (i Item) FileID() (uint64, bool) {
+	return i.id, i.kind == itemFile
+}
+
+// FolderID returns the folder ID and whether i refers to a folder by ID.
+func (i Item) FolderID() (uint64, bool) {
+	return i.id, i.kind == itemFolder
+}
+
+// Path returns the path and whether i refers to an item by path.
+func (i Item) Path() (string, bool) {
+	return i.path, i.kind == itemPath
+}
+
+var errNoItem = errors.New("empty item reference")
+
+// params adds the parameters selecting i, with prefix "to" for targets.
+func (i Item) params(params url.Values, prefix string) error {
+	switch i.kind {
+	case itemFile:
+		params.Set(prefix+"fileid", strconv.FormatUint(i.id, 10))
+	case itemFolder:
+		params.Set(prefix+"folderid", strconv.FormatUint(i.id, 10))
+	case itemPath:
+		params.Set(prefix+"path", i.path)
+	default:
+		return errNoItem
+	}
+	return nil
+}
+
+// targetParams selects the destination folder of a move or copy, optionally
+// with a new name.
+func (i Item) targetParams(params url.Values, name string) error {
+	switch i.kind {
+	case itemFolder:
+		params.Set("tofolderid", strconv.FormatUint(i.id, 10))
+		if name != "" {
+			params.Set("toname", name)
+		}
+	case itemPath:
+		params.Set("topath", strings.TrimSuffix(i.path, "/")+"/"+name)
+	case itemFile:
+		return fmt.Errorf("target %s is not a folder", i)
+	default:
+		return errNoItem
+	}
+	return nil
+}
+
+// isFolder reports whether i is a folder, looking paths up with stat.
+func (c *Client) isFolder(ctx context.Context, i Item) (bool, error) {
+	switch i.kind {
+	case itemFile:
+		return false, nil
+	case itemFolder:
+		return true, nil
+	case itemPath:
+		meta, err := c.StatItem(ctx, i)
+		if err != nil {
+			return false, err
+		}
+		return meta.IsFolder, nil
+	default:
+		return false, errNoItem
+	}
+}
+
+func (c *Client) itemCall(ctx context.Context, i Item, fileMethod, folderMethod string, params url.Values) (*Metadata, error) {
+	folder, err := c.isFolder(ctx, i)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.params(params, ""); err != nil {
+		return nil, err
+	}
+
+	method := fileMethod
+	if folder {
+		method = folderMethod
+	}
+	var resp fileResponse
+	if err := c.do(ctx, method, params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Metadata, nil
+}
+
+// StatItem returns the metadata of i. The stat method only accepts files
+// and paths, so folders referred to by ID are looked up with a listing that
+// leaves out files and subfolder contents; Contents is always empty.
+func (c *Client) StatItem(ctx context.Context, i Item) (*Metadata, error) {
+	if i.kind == itemFolder {
+		meta, err := c.ListFolderItem(ctx, i, &ListFolderOpts{NoFiles: true})
+		if err != nil {
+			return nil, err
+		}
+		meta.Contents = nil
+		return meta, nil
+	}
+
+	params := url.Values{}
+	if err := i.params(params, ""); err != nil {
+		return nil, err
+	}
+
+	var resp fileResponse
+	if err := c.do(ctx, "stat", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Metadata, nil
+}
+
+// RemoveItem deletes a file or an empty folder. Use DeleteFolderRecursive
+// to delete a folder with its contents.
+func (c *Client) RemoveItem(ctx context.Context, i Item) error {
+	_, err := c.itemCall(ctx, i, "deletefile", "deletefolder", url.Values{})
+	return err
+}
+
+func (c *Client) RenameItem(ctx context.Context, i Item, newName string) (*Metadata, error) {
+	params := url.Values{
+		"toname": {newName},
+	}
+	return c.itemCall(ctx, i, "renamefile", "renamefolder", params)
+}
+
+// MoveItem moves i into the folder toFolder. A non-empty name renames it on
+// the way.
+func (c *Client) MoveItem(ctx context.Context, i, toFolder Item, name string) (*Metadata, error) {
+	params := url.Values{}
+	if err := toFolder.targetParams(params, name); err != nil {
+		return nil, err
+	}
+	return c.itemCall(ctx, i, "renamefile", "renamefolder", params)
+}
+
+func (c *Client) CopyItem(ctx context.Context, i, toFolder Item) (*Metadata, error) {
+	params := url.Values{}
+	if err := toFolder.targetParams(params, ""); err != nil {
+		return nil, err
+	}
+	return c.itemCall(ctx, i, "copyfile", "copyfolder", params)
+}
+
+func (c *Client) ListFolderItem(ctx context.Context, folder Item, opts *ListFolderOpts) (*Metadata, error) {
+	params := url.Values{}
+	if err := folder.params(params, ""); err != nil {
+		return nil, err
+	}
+	applyListFolderOpts(params, opts)
+
+	var resp folderResponse
+	if err := c.do(ctx, "listfolder", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Metadata, nil
+}
+
+func (c *Client) UploadItem(ctx context.Context, folder Item, filename string, content io.Reader, opts *UploadOpts) (*Metadata, error) {
+	params := url.Values{
+		"filename": {filename},
+	}
+	if err := folder.params(params, ""); err != nil {
+		return nil, err
+	}
+	return c.upload(ctx, params, filename, content, opts)
+}
+
+func (c *Client) DownloadItem(ctx context.Context, file Item, opts *DownloadOpts) (io.ReadCloser, error) {
+	params := url.Values{}
+	if err := file.params(params, ""); err != nil {
+		return nil, err
+	}
+
+	var link FileLink
+	if err := c.do(ctx, "getfilelink", params, &link); err != nil {
+		return nil, err
+	}
+	return c.downloadFromLink(ctx, &link, opts)
+}
+
+func (c *Client) ListRevisionsItem(ctx context.Context, file Item) ([]Revision, error) {
+	params := url.Values{}
+	if err := file.params(params, ""); err != nil {
+		return nil, err
+	}
+
+	var resp revisionsResponse
+	if err := c.do(ctx, "listrevisions", params, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Revisions, nil
+}
@@ -0,0 +1,155 @@
+package pcloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestParseItem(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Item
+		wantErr bool
+	}{
+		{in: "", wantErr: true},
+		{in: "f", wantErr: true},
+		{in: "x1", wantErr: true},
+		{in: "f-1", wantErr: true},
+		{in: "d1x", wantErr: true},
+		{in: "/", want: PathItem("/")},
+		{in: "/a/b.txt", want: PathItem("/a/b.txt")},
+		{in: "d0", want: FolderItem(0)},
+		{in: "f123", want: FileItem(123)},
+		{in: "d18446744073709551615", want: FolderItem(18446744073709551615)},
+	}
+	for _, tt := range tests {
+		got, err := ParseItem(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseItem(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseItem(%q): %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseItem(%q) = %#v, want %#v", tt.in, got, tt.want)
+		}
+		if got.String() != tt.in {
+			t.Errorf("ParseItem(%q).String() = %q", tt.in, got.String())
+		}
+	}
+}
+
+func TestItemMethods(t *testing.T) {
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		r.Form.Del("auth")
+		method := r.URL.Path[1:]
+		calls = append(calls, method+" "+r.Form.Encode())
+		if method == "stat" && r.Form.Get("path") == "/dir" {
+			fmt.Fprint(w, `{"result":0,"metadata":{"name":"dir","isfolder":true,"folderid":5}}`)
+			return
+		}
+		fmt.Fprint(w, `{"result":0,"metadata":{"name":"x","isfolder":false,"fileid":3,"contents":[{"name":"sub","isfolder":true}]}}`)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, WithAuthToken("tok"), WithRateLimit(60000))
+	ctx := context.Background()
+	file, folder, filePath, folderPath := FileItem(3), FolderItem(5), PathItem("/a.txt"), PathItem("/dir")
+
+	tests := []struct {
+		name string
+		call func() error
+		want []string
+	}{
+		{"stat file", func() error { _, err := c.StatItem(ctx, file); return err }, []string{"stat fileid=3"}},
+		{"stat folder", func() error { _, err := c.StatItem(ctx, folder); return err }, []string{"listfolder folderid=5&nofiles=1"}},
+		{"stat path", func() error { _, err := c.StatItem(ctx, filePath); return err }, []string{"stat path=%2Fa.txt"}},
+		{"remove file", func() error { return c.RemoveItem(ctx, file) }, []string{"deletefile fileid=3"}},
+		{"remove folder", func() error { return c.RemoveItem(ctx, folder) }, []string{"deletefolder folderid=5"}},
+		{"remove file path", func() error { return c.RemoveItem(ctx, filePath) }, []string{
+			"stat path=%2Fa.txt",
+			"deletefile path=%2Fa.txt",
+		}},
+		{"remove folder path", func() error { return c.RemoveItem(ctx, folderPath) }, []string{
+			"stat path=%2Fdir",
+			"deletefolder path=%2Fdir",
+		}},
+		{"rename file", func() error { _, err := c.RenameItem(ctx, file, "b.txt"); return err }, []string{
+			"renamefile fileid=3&toname=b.txt",
+		}},
+		{"rename folder path", func() error { _, err := c.RenameItem(ctx, folderPath, "new"); return err }, []string{
+			"stat path=%2Fdir",
+			"renamefolder path=%2Fdir&toname=new",
+		}},
+		{"move file to folder", func() error { _, err := c.MoveItem(ctx, file, folder, ""); return err }, []string{
+			"renamefile fileid=3&tofolderid=5",
+		}},
+		{"move folder to folder with name", func() error { _, err := c.MoveItem(ctx, folder, FolderItem(6), "new"); return err }, []string{
+			"renamefolder folderid=5&tofolderid=6&toname=new",
+		}},
+		{"move file to path", func() error { _, err := c.MoveItem(ctx, file, PathItem("/dir/"), ""); return err }, []string{
+			"renamefile fileid=3&topath=%2Fdir%2F",
+		}},
+		{"move path to path with name", func() error { _, err := c.MoveItem(ctx, filePath, folderPath, "b.txt"); return err }, []string{
+			"stat path=%2Fa.txt",
+			"renamefile path=%2Fa.txt&topath=%2Fdir%2Fb.txt",
+		}},
+		{"copy file", func() error { _, err := c.CopyItem(ctx, file, folder); return err }, []string{
+			"copyfile fileid=3&tofolderid=5",
+		}},
+		{"copy folder to path", func() error { _, err := c.CopyItem(ctx, folder, PathItem("/")); return err }, []string{
+			"copyfolder folderid=5&topath=%2F",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			if err := tt.call(); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(calls, tt.want) {
+				t.Errorf("calls = %q, want %q", calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatFolderItem(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"result":0,"metadata":{"name":"dir","isfolder":true,"folderid":5,"contents":[{"name":"sub","isfolder":true,"folderid":6}]}}`)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, WithAuthToken("tok"), WithRateLimit(60000))
+	meta, err := c.StatItem(context.Background(), FolderItem(5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Name != "dir" || !meta.IsFolder || meta.FolderID != 5 || meta.Contents != nil {
+		t.Errorf("StatItem = %+v", meta)
+	}
+}
+
+func TestItemInvalidTarget(t *testing.T) {
+	c := NewClient("http://127.0.0.1:0", WithAuthToken("tok"))
+	ctx := context.Background()
+	if _, err := c.MoveItem(ctx, FileItem(1), FileItem(2), ""); err == nil {
+		t.Error("MoveItem to a file: expected an error")
+	}
+	if _, err := c.CopyItem(ctx, FileItem(1), Item{}); err == nil {
+		t.Error("CopyItem to an empty item: expected an error")
+	}
+	if err := c.RemoveItem(ctx, Item{}); err == nil {
+		t.Error("RemoveItem of an empty item: expected an error")
+	}
+}
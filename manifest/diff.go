@@ -0,0 +1,120 @@
+package manifest
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/yanmhlv/pcloud"
+)
+
+type ChangeKind string
+
+const (
+	Added    ChangeKind = "added"
+	Removed  ChangeKind = "removed"
+	Modified ChangeKind = "modified"
+	Moved    ChangeKind = "moved"
+)
+
+// Change describes one item that differs between two manifests. Old is nil
+// for added items and New is nil for removed ones.
+type Change struct {
+	Kind ChangeKind
+	Old  *pcloud.Metadata
+	New  *pcloud.Metadata
+}
+
+func (ch Change) Path() string {
+	if ch.New != nil {
+		return ch.New.Path
+	}
+	return ch.Old.Path
+}
+
+type Changes struct {
+	Added    []Change
+	Removed  []Change
+	Modified []Change
+	Moved    []Change
+}
+
+func (c *Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Modified) == 0 && len(c.Moved) == 0
+}
+
+func (c *Changes) String() string {
+	var sb strings.Builder
+	for _, group := range [][]Change{c.Added, c.Removed, c.Modified, c.Moved} {
+		for _, ch := range group {
+			switch ch.Kind {
+			case Moved:
+				fmt.Fprintf(&sb, "%-8s %q -> %q\n", ch.Kind, ch.Old.Path, ch.New.Path)
+			case Modified:
+				fmt.Fprintf(&sb, "%-8s %q size=%d->%d hash=%s->%s\n", ch.Kind, ch.New.Path, ch.Old.Size, ch.New.Size, ch.Old.Hash, ch.New.Hash)
+			case Added, Removed:
+				fmt.Fprintf(&sb, "%-8s %q\n", ch.Kind, ch.Path())
+			}
+		}
+	}
+	fmt.Fprintf(&sb, "added: %d, removed: %d, modified: %d, moved: %d\n", len(c.Added), len(c.Removed), len(c.Modified), len(c.Moved))
+	return sb.String()
+}
+
+// Diff compares two manifests of the same tree. Items are matched by their
+// file or folder ID. A matched item is moved when its parent folder or name
+// changed, so the contents of a moved folder are not reported themselves,
+// and modified when Metadata.ContentChanged says so. An item can be both.
+func Diff(before, after *Manifest) *Changes {
+	old := index(before)
+	changes := &Changes{}
+
+	seen := map[string]bool{}
+	for i := range after.Items {
+		cur := &after.Items[i]
+		key := itemKey(cur)
+		seen[key] = true
+
+		prev, ok := old[key]
+		if !ok {
+			changes.Added = append(changes.Added, Change{Kind: Added, New: cur})
+			continue
+		}
+		if prev.ParentID != cur.ParentID || prev.Name != cur.Name {
+			changes.Moved = append(changes.Moved, Change{Kind: Moved, Old: prev, New: cur})
+		}
+		if cur.ContentChanged(prev) {
+			changes.Modified = append(changes.Modified, Change{Kind: Modified, Old: prev, New: cur})
+		}
+	}
+	for i := range before.Items {
+		prev := &before.Items[i]
+		if !seen[itemKey(prev)] {
+			changes.Removed = append(changes.Removed, Change{Kind: Removed, Old: prev})
+		}
+	}
+
+	for _, group := range [][]Change{changes.Added, changes.Removed, changes.Modified, changes.Moved} {
+		slices.SortFunc(group, func(a, b Change) int {
+			return cmp.Compare(a.Path(), b.Path())
+		})
+	}
+	return changes
+}
+
+func index(m *Manifest) map[string]*pcloud.Metadata {
+	out := make(map[string]*pcloud.Metadata, len(m.Items))
+	for i := range m.Items {
+		out[itemKey(&m.Items[i])] = &m.Items[i]
+	}
+	return out
+}
+
+func itemKey(item *pcloud.Metadata) string {
+	if item.IsFolder {
+		return "d" + strconv.FormatUint(item.FolderID, 10)
+	}
+	return "f" + strconv.FormatUint(item.FileID, 10)
+}
@@ -0,0 +1,98 @@
+package manifest
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/yanmhlv/pcloud"
+)
+
+func testFile(id, parent uint64, path string, hash pcloud.Hash) pcloud.Metadata {
+	return pcloud.Metadata{Path: path, Name: path[strings.LastIndex(path, "/")+1:], FileID: id, ParentID: parent, Hash: hash}
+}
+
+func testFolder(id, parent uint64, path string) pcloud.Metadata {
+	return pcloud.Metadata{Path: path, Name: path[strings.LastIndex(path, "/")+1:], IsFolder: true, FolderID: id, ParentID: parent}
+}
+
+func changePaths(changes []Change) []string {
+	var paths []string
+	for _, ch := range changes {
+		paths = append(paths, ch.Path())
+	}
+	return paths
+}
+
+func TestDiff(t *testing.T) {
+	before := &Manifest{RootID: 1, Items: []pcloud.Metadata{
+		testFolder(2, 1, "/docs"),
+		testFile(10, 2, "/docs/same.txt", 100),
+		testFile(11, 2, "/docs/edited.txt", 101),
+		testFile(12, 2, "/docs/gone.txt", 102),
+		testFile(13, 2, "/docs/renamed.txt", 103),
+		testFile(14, 2, "/docs/moved-and-edited.txt", 104),
+		testFolder(3, 1, "/photos"),
+		testFolder(4, 3, "/photos/2024"),
+		testFile(15, 4, "/photos/2024/a.jpg", 105),
+		testFolder(5, 1, "/archive"),
+	}}
+	after := &Manifest{RootID: 1, Items: []pcloud.Metadata{
+		testFolder(2, 1, "/docs"),
+		testFile(10, 2, "/docs/same.txt", 100),
+		testFile(11, 2, "/docs/edited.txt", 201),
+		testFile(13, 2, "/docs/new-name.txt", 103),
+		testFile(16, 2, "/docs/new.txt", 106),
+		testFolder(3, 1, "/photos"),
+		testFolder(5, 1, "/archive"),
+		testFile(14, 5, "/archive/moved-and-edited.txt", 204),
+		testFolder(4, 5, "/archive/2024"),
+		testFile(15, 4, "/archive/2024/a.jpg", 105),
+	}}
+
+	changes := Diff(before, after)
+	tests := []struct {
+		kind ChangeKind
+		got  []Change
+		want []string
+	}{
+		{Added, changes.Added, []string{"/docs/new.txt"}},
+		{Removed, changes.Removed, []string{"/docs/gone.txt"}},
+		{Modified, changes.Modified, []string{"/archive/moved-and-edited.txt", "/docs/edited.txt"}},
+		{Moved, changes.Moved, []string{"/archive/2024", "/archive/moved-and-edited.txt", "/docs/new-name.txt"}},
+	}
+	for _, tt := range tests {
+		if got := changePaths(tt.got); !slices.Equal(got, tt.want) {
+			t.Errorf("%s = %q, want %q", tt.kind, got, tt.want)
+		}
+		for _, ch := range tt.got {
+			if ch.Kind != tt.kind {
+				t.Errorf("%s: change kind = %s", ch.Path(), ch.Kind)
+			}
+		}
+	}
+
+	moved := changes.Moved[1]
+	if moved.Old.Path != "/docs/moved-and-edited.txt" || moved.New.Path != "/archive/moved-and-edited.txt" {
+		t.Errorf("moved = %q -> %q", moved.Old.Path, moved.New.Path)
+	}
+	if changes.Added[0].Old != nil || changes.Removed[0].New != nil {
+		t.Error("added and removed changes must only have one side")
+	}
+	if changes.Empty() {
+		t.Error("Empty() = true")
+	}
+	if !Diff(before, before).Empty() {
+		t.Errorf("diff of identical manifests:\n%s", Diff(before, before))
+	}
+}
+
+func TestDiffFileReplacedByFolder(t *testing.T) {
+	before := &Manifest{Items: []pcloud.Metadata{testFile(7, 1, "/x", 1)}}
+	after := &Manifest{Items: []pcloud.Metadata{testFolder(7, 1, "/x")}}
+
+	changes := Diff(before, after)
+	if !slices.Equal(changePaths(changes.Added), []string{"/x"}) || !slices.Equal(changePaths(changes.Removed), []string{"/x"}) {
+		t.Errorf("changes:\n%s", changes)
+	}
+}
@@ -0,0 +1,51 @@
+package manifest_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/yanmhlv/pcloud"
+	"github.com/yanmhlv/pcloud/manifest"
+)
+
+func ExampleDiff() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	f, err := os.Open("photos-2024-01-01.jsonl")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	before, err := manifest.Read(f, manifest.FormatJSONL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	after, err := manifest.Snapshot(ctx, c, before.RootID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Print(manifest.Diff(before, after))
+}
+
+func ExampleSnapshot() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	m, err := manifest.Snapshot(ctx, c, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := m.Write(os.Stdout, manifest.FormatCSV); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,242 @@
+// Package manifest snapshots pCloud folder trees for offline auditing.
+//
+// Snapshot walks a folder and records every file and folder with its path
+// relative to the root, size, content hash and timestamps. A Manifest can be
+// written as JSON Lines or CSV, read back later, and compared with another
+// snapshot of the same tree:
+//
+//	before, _ := manifest.Snapshot(ctx, c, folderID)
+//	before.Write(f, manifest.FormatJSONL)
+//
+//	// later
+//	before, _ := manifest.Read(f, manifest.FormatJSONL)
+//	after, _ := manifest.Snapshot(ctx, c, folderID)
+//	fmt.Print(manifest.Diff(before, after))
+package manifest
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yanmhlv/pcloud"
+)
+
+type Format string
+
+const (
+	FormatJSONL Format = "jsonl"
+	FormatCSV   Format = "csv"
+)
+
+// Manifest lists the items of a folder tree in walk order. Item paths are
+// relative to the root folder and start with "/"; Contents is always empty.
+// JSON Lines keeps every Metadata field, while CSV keeps the identifying,
+// size, hash and time columns that Diff needs.
+type Manifest struct {
+	RootID uint64
+	Items  []pcloud.Metadata
+}
+
+func Snapshot(ctx context.Context, c *pcloud.Client, folderID uint64) (*Manifest, error) {
+	m := &Manifest{RootID: folderID}
+	paths := map[uint64]string{folderID: ""}
+	for item, err := range c.Walk(ctx, folderID) {
+		if err != nil {
+			return nil, err
+		}
+		item.Path = paths[item.ParentID] + "/" + item.Name
+		if item.IsFolder {
+			paths[item.FolderID] = item.Path
+		}
+		item.Contents = nil
+		m.Items = append(m.Items, item)
+	}
+	return m, nil
+}
+
+func (m *Manifest) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatJSONL:
+		return m.writeJSONL(w)
+	case FormatCSV:
+		return m.writeCSV(w)
+	default:
+		return fmt.Errorf("manifest: unknown format %q", format)
+	}
+}
+
+func Read(r io.Reader, format Format) (*Manifest, error) {
+	switch format {
+	case FormatJSONL:
+		return readJSONL(r)
+	case FormatCSV:
+		return readCSV(r)
+	default:
+		return nil, fmt.Errorf("manifest: unknown format %q", format)
+	}
+}
+
+func (m *Manifest) writeJSONL(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, item := range m.Items {
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readJSONL(r io.Reader) (*Manifest, error) {
+	m := &Manifest{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var item pcloud.Metadata
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			return nil, fmt.Errorf("manifest: line %d: %w", line, err)
+		}
+		m.Items = append(m.Items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	m.setRoot()
+	return m, nil
+}
+
+var csvHeader = []string{
+	"id", "path", "name", "isfolder", "parentfolderid", "fileid", "folderid",
+	"size", "hash", "category", "contenttype", "created", "modified",
+}
+
+func (m *Manifest) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, item := range m.Items {
+		record := []string{
+			item.ID,
+			item.Path,
+			item.Name,
+			strconv.FormatBool(item.IsFolder),
+			strconv.FormatUint(item.ParentID, 10),
+			strconv.FormatUint(item.FileID, 10),
+			strconv.FormatUint(item.FolderID, 10),
+			strconv.FormatUint(item.Size, 10),
+			item.Hash.String(),
+			strconv.Itoa(int(item.Category)),
+			item.ContentType,
+			formatTime(item.Created),
+			formatTime(item.Modified),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func readCSV(r io.Reader) (*Manifest, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return &Manifest{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Equal(header, csvHeader) {
+		return nil, fmt.Errorf("manifest: unexpected CSV header %q", header)
+	}
+
+	m := &Manifest{}
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		item, err := parseRecord(record)
+		if err != nil {
+			line, _ := cr.FieldPos(0)
+			return nil, fmt.Errorf("manifest: line %d: %w", line, err)
+		}
+		m.Items = append(m.Items, item)
+	}
+	m.setRoot()
+	return m, nil
+}
+
+func parseRecord(record []string) (pcloud.Metadata, error) {
+	item := pcloud.Metadata{
+		ID:          record[0],
+		Path:        record[1],
+		Name:        record[2],
+		ContentType: record[10],
+	}
+
+	var err error
+	parseUint := func(s string) uint64 {
+		n, perr := strconv.ParseUint(s, 10, 64)
+		err = errors.Join(err, perr)
+		return n
+	}
+	parseTime := func(s string) pcloud.Time {
+		if s == "" {
+			return pcloud.Time{}
+		}
+		t, perr := time.Parse(time.RFC1123Z, s)
+		err = errors.Join(err, perr)
+		return pcloud.Time{Time: t}
+	}
+
+	isFolder, perr := strconv.ParseBool(record[3])
+	err = errors.Join(err, perr)
+	category, perr := strconv.Atoi(record[9])
+	err = errors.Join(err, perr)
+
+	item.IsFolder = isFolder
+	item.ParentID = parseUint(record[4])
+	item.FileID = parseUint(record[5])
+	item.FolderID = parseUint(record[6])
+	item.Size = parseUint(record[7])
+	item.Hash = pcloud.Hash(parseUint(record[8]))
+	item.Category = pcloud.Category(category)
+	item.Created = parseTime(record[11])
+	item.Modified = parseTime(record[12])
+	return item, err
+}
+
+func formatTime(t pcloud.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC1123Z)
+}
+
+// setRoot recovers RootID from a loaded manifest: the parent of the items
+// at the top of the tree.
+func (m *Manifest) setRoot() {
+	for _, item := range m.Items {
+		if strings.Count(item.Path, "/") == 1 {
+			m.RootID = item.ParentID
+			return
+		}
+	}
+}
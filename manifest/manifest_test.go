@@ -0,0 +1,131 @@
+package manifest
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yanmhlv/pcloud"
+)
+
+func testManifest() *Manifest {
+	created := pcloud.Time{Time: time.Date(2024, 3, 5, 14, 7, 9, 0, time.UTC)}
+	modified := pcloud.Time{Time: created.Add(time.Hour)}
+	return &Manifest{
+		RootID: 1,
+		Items: []pcloud.Metadata{
+			{ID: "d2", Path: "/docs", Name: "docs", IsFolder: true, ParentID: 1, FolderID: 2, Created: created, Modified: modified},
+			{
+				ID: "f3", Path: "/docs/a, \"quoted\".txt", Name: "a, \"quoted\".txt", ParentID: 2, FileID: 3,
+				Size: 12, Hash: 18446744073709551615, Category: pcloud.CategoryDocument, ContentType: "text/plain",
+				Created: created, Modified: modified,
+			},
+			{ID: "f4", Path: "/empty.bin", Name: "empty.bin", ParentID: 1, FileID: 4},
+		},
+	}
+}
+
+// equalItems compares items, with times compared by instant.
+func equalItems(t *testing.T, got, want []pcloud.Metadata) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d items, want %d", len(got), len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if !g.Created.Equal(w.Created.Time) || !g.Modified.Equal(w.Modified.Time) {
+			t.Errorf("item %d times = %v %v, want %v %v", i, g.Created, g.Modified, w.Created, w.Modified)
+		}
+		g.Created, g.Modified = pcloud.Time{}, pcloud.Time{}
+		w.Created, w.Modified = pcloud.Time{}, pcloud.Time{}
+		if !reflect.DeepEqual(g, w) {
+			t.Errorf("item %d:\ngot  %+v\nwant %+v", i, g, w)
+		}
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	for _, format := range []Format{FormatJSONL, FormatCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			want := testManifest()
+			var buf bytes.Buffer
+			if err := want.Write(&buf, format); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Read(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.RootID != want.RootID {
+				t.Errorf("RootID = %d, want %d", got.RootID, want.RootID)
+			}
+			equalItems(t, got.Items, want.Items)
+		})
+	}
+}
+
+func TestWriteReadJSONLMedia(t *testing.T) {
+	want := &Manifest{Items: []pcloud.Metadata{{
+		ID: "f5", Path: "/clip.mp4", Name: "clip.mp4", FileID: 5, Category: pcloud.CategoryVideo,
+		Video: &pcloud.VideoInfo{Width: 640, Height: 360, Duration: 5 * time.Second},
+	}}}
+	var buf bytes.Buffer
+	if err := want.Write(&buf, FormatJSONL); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(&buf, FormatJSONL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	equalItems(t, got.Items, want.Items)
+}
+
+func TestReadEmpty(t *testing.T) {
+	for _, format := range []Format{FormatJSONL, FormatCSV} {
+		m, err := Read(strings.NewReader(""), format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if len(m.Items) != 0 {
+			t.Errorf("%s: got %d items", format, len(m.Items))
+		}
+	}
+}
+
+func TestReadCSVInvalid(t *testing.T) {
+	header := strings.Join(csvHeader, ",") + "\n"
+	tests := map[string]string{
+		"bad header":    "id,path,name\nf1,/a,a\n",
+		"bad bool":      header + "f1,/a,a,maybe,1,1,0,3,0,0,,,\n",
+		"bad size":      header + "f1,/a,a,false,1,1,0,-3,0,0,,,\n",
+		"bad hash":      header + "f1,/a,a,false,1,1,0,3,abc,0,,,\n",
+		"bad category":  header + "f1,/a,a,false,1,1,0,3,0,x,,,\n",
+		"bad time":      header + "f1,/a,a,false,1,1,0,3,0,0,,yesterday,\n",
+		"missing field": header + "f1,/a,a,false\n",
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Read(strings.NewReader(data), FormatCSV); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestReadJSONLInvalid(t *testing.T) {
+	_, err := Read(strings.NewReader("{\"id\":\"f1\"}\n\n{\"created\":\"yesterday\"}\n"), FormatJSONL)
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("err = %v, want an error on line 3", err)
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if err := testManifest().Write(&bytes.Buffer{}, "xml"); err == nil {
+		t.Error("Write: expected an error")
+	}
+	if _, err := Read(strings.NewReader(""), "xml"); err == nil {
+		t.Error("Read: expected an error")
+	}
+}
@@ -0,0 +1,186 @@
+package pcloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+type Category int
+
+const (
+	CategoryUncategorized Category = iota
+	CategoryImage
+	CategoryVideo
+	CategoryAudio
+	CategoryDocument
+	CategoryArchive
+)
+
+func (c Category) String() string {
+	switch c {
+	case CategoryUncategorized:
+		return "uncategorized"
+	case CategoryImage:
+		return "image"
+	case CategoryVideo:
+		return "video"
+	case CategoryAudio:
+		return "audio"
+	case CategoryDocument:
+		return "document"
+	case CategoryArchive:
+		return "archive"
+	default:
+		return "category(" + strconv.Itoa(int(c)) + ")"
+	}
+}
+
+type ImageInfo struct {
+	Width  int
+	Height int
+}
+
+type VideoInfo struct {
+	Width           int
+	Height          int
+	Duration        time.Duration
+	FPS             float64
+	Rotate          int
+	VideoCodec      string
+	VideoBitrate    int
+	AudioCodec      string
+	AudioBitrate    int
+	AudioSampleRate int
+}
+
+type AudioInfo struct {
+	Artist   string
+	Album    string
+	Title    string
+	Genre    string
+	Track    string
+	Duration time.Duration
+}
+
+// mediaJSON holds the media fields pCloud returns alongside file metadata.
+// Depending on the endpoint numbers may arrive as JSON strings.
+type mediaJSON struct {
+	Width           mediaNumber `json:"width,omitempty"`
+	Height          mediaNumber `json:"height,omitempty"`
+	Duration        mediaNumber `json:"duration,omitempty"`
+	FPS             mediaNumber `json:"fps,omitempty"`
+	Rotate          mediaNumber `json:"rotate,omitempty"`
+	VideoCodec      string      `json:"videocodec,omitempty"`
+	VideoBitrate    mediaNumber `json:"videobitrate,omitempty"`
+	AudioCodec      string      `json:"audiocodec,omitempty"`
+	AudioBitrate    mediaNumber `json:"audiobitrate,omitempty"`
+	AudioSampleRate mediaNumber `json:"audiosamplerate,omitempty"`
+	Artist          string      `json:"artist,omitempty"`
+	Album           string      `json:"album,omitempty"`
+	Title           string      `json:"title,omitempty"`
+	Genre           string      `json:"genre,omitempty"`
+	Track           mediaString `json:"trno,omitempty"`
+}
+
+func (j *mediaJSON) apply(m *Metadata) {
+	switch m.Category {
+	case CategoryImage:
+		if j.Width != 0 || j.Height != 0 {
+			m.Image = &ImageInfo{Width: int(j.Width), Height: int(j.Height)}
+		}
+	case CategoryVideo:
+		if *j != (mediaJSON{}) {
+			m.Video = &VideoInfo{
+				Width:           int(j.Width),
+				Height:          int(j.Height),
+				Duration:        seconds(j.Duration),
+				FPS:             float64(j.FPS),
+				Rotate:          int(j.Rotate),
+				VideoCodec:      j.VideoCodec,
+				VideoBitrate:    int(j.VideoBitrate),
+				AudioCodec:      j.AudioCodec,
+				AudioBitrate:    int(j.AudioBitrate),
+				AudioSampleRate: int(j.AudioSampleRate),
+			}
+		}
+	case CategoryAudio:
+		if *j != (mediaJSON{}) {
+			m.Audio = &AudioInfo{
+				Artist:   j.Artist,
+				Album:    j.Album,
+				Title:    j.Title,
+				Genre:    j.Genre,
+				Track:    string(j.Track),
+				Duration: seconds(j.Duration),
+			}
+		}
+	}
+}
+
+func newMediaJSON(m *Metadata) mediaJSON {
+	var j mediaJSON
+	if img := m.Image; img != nil {
+		j.Width = mediaNumber(img.Width)
+		j.Height = mediaNumber(img.Height)
+	}
+	if v := m.Video; v != nil {
+		j.Width = mediaNumber(v.Width)
+		j.Height = mediaNumber(v.Height)
+		j.Duration = mediaNumber(v.Duration.Seconds())
+		j.FPS = mediaNumber(v.FPS)
+		j.Rotate = mediaNumber(v.Rotate)
+		j.VideoCodec = v.VideoCodec
+		j.VideoBitrate = mediaNumber(v.VideoBitrate)
+		j.AudioCodec = v.AudioCodec
+		j.AudioBitrate = mediaNumber(v.AudioBitrate)
+		j.AudioSampleRate = mediaNumber(v.AudioSampleRate)
+	}
+	if a := m.Audio; a != nil {
+		j.Artist = a.Artist
+		j.Album = a.Album
+		j.Title = a.Title
+		j.Genre = a.Genre
+		j.Track = mediaString(a.Track)
+		j.Duration = mediaNumber(a.Duration.Seconds())
+	}
+	return j
+}
+
+func seconds(n mediaNumber) time.Duration {
+	return time.Duration(float64(n) * float64(time.Second))
+}
+
+type mediaNumber float64
+
+func (n *mediaNumber) UnmarshalJSON(data []byte) error {
+	data = bytes.Trim(data, `"`)
+	if len(data) == 0 || string(data) == "null" {
+		*n = 0
+		return nil
+	}
+	f, err := strconv.ParseFloat(string(data), 64)
+	if err != nil {
+		return fmt.Errorf("media: cannot unmarshal %s as number", string(data))
+	}
+	*n = mediaNumber(f)
+	return nil
+}
+
+type mediaString string
+
+func (s *mediaString) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*s = mediaString(str)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("media: cannot unmarshal %s as string", string(data))
+	}
+	*s = mediaString(n.String())
+	return nil
+}
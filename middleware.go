@@ -0,0 +1,105 @@
+package pcloud
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type CallKind string
+
+const (
+	CallAPI      CallKind = "api"
+	CallUpload   CallKind = "upload"
+	CallContent  CallKind = "content"
+	CallDownload CallKind = "download"
+)
+
+// Call describes a single request as seen by middleware. Params holds the
+// request parameters with credentials replaced by "REDACTED"; a middleware
+// may add, change or remove parameters, and redacted values it leaves in
+// place are restored before the request is sent.
+type Call struct {
+	Method        string
+	Kind          CallKind
+	Params        url.Values
+	Header        http.Header
+	URL           string
+	ContentLength int64
+
+	secrets     url.Values
+	body        io.Reader
+	contentType string
+}
+
+// CallResponse is what a Handler returns. JSON API responses are read in
+// full into Raw and their result code is decoded into Result; file content
+// and downloads are left unread in Body. A middleware that short-circuits a
+// call must fill in whichever of the two the call's Kind expects.
+type CallResponse struct {
+	HTTPStatus    int
+	Result        int
+	Host          string
+	Retries       int
+	Duration      time.Duration
+	ContentLength int64
+	Raw           json.RawMessage
+	Body          io.ReadCloser
+}
+
+type Handler func(ctx context.Context, call *Call) (*CallResponse, error)
+
+// Middleware wraps a Handler. Middleware registered first runs outermost,
+// so it sees the call before and the response after everything added later.
+type Middleware func(next Handler) Handler
+
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *Client) {
+		c.middleware = append(append([]Middleware(nil), c.middleware...), mw...)
+	}
+}
+
+func (c *Client) Use(mw ...Middleware) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.middleware = append(append([]Middleware(nil), c.middleware...), mw...)
+}
+
+func chain(middleware []Middleware, h Handler) Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
+
+func newCall(method string, kind CallKind, params url.Values) *Call {
+	secrets := url.Values{}
+	for key, values := range params {
+		if sensitiveParams[key] {
+			secrets[key] = values
+		}
+	}
+	return &Call{
+		Method:        method,
+		Kind:          kind,
+		Params:        redactParams(params),
+		Header:        http.Header{},
+		ContentLength: -1,
+		secrets:       secrets,
+	}
+}
+
+func (call *Call) requestParams() url.Values {
+	params := make(url.Values, len(call.Params))
+	for key, values := range call.Params {
+		if len(values) == 1 && values[0] == redacted && call.secrets.Has(key) {
+			params[key] = call.secrets[key]
+			continue
+		}
+		params[key] = values
+	}
+	return params
+}
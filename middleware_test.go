@@ -0,0 +1,242 @@
+package pcloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recordedRequests struct {
+	mu    sync.Mutex
+	forms []url.Values
+}
+
+func (r *recordedRequests) add(form url.Values) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.forms = append(r.forms, form)
+}
+
+func (r *recordedRequests) last() url.Values {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.forms) == 0 {
+		return nil
+	}
+	return r.forms[len(r.forms)-1]
+}
+
+func formServer(t *testing.T) (*httptest.Server, *recordedRequests) {
+	t.Helper()
+	reqs := &recordedRequests{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		reqs.add(r.Form)
+		fmt.Fprint(w, `{"result":0,"email":"server@example.com"}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, reqs
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	srv, _ := formServer(t)
+
+	var mu sync.Mutex
+	var trace []string
+	tracer := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, call *Call) (*CallResponse, error) {
+				mu.Lock()
+				trace = append(trace, name+" before")
+				mu.Unlock()
+				resp, err := next(ctx, call)
+				mu.Lock()
+				trace = append(trace, name+" after")
+				mu.Unlock()
+				return resp, err
+			}
+		}
+	}
+
+	c := NewClient(srv.URL, WithRateLimit(60000), WithMiddleware(tracer("a"), tracer("b")))
+	c.Use(tracer("c"))
+	derived := c.With(WithMiddleware(tracer("d")))
+
+	if _, err := c.UserInfo(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"a before", "b before", "c before", "c after", "b after", "a after"}
+	if !slices.Equal(trace, want) {
+		t.Errorf("trace = %q, want %q", trace, want)
+	}
+
+	trace = nil
+	if _, err := derived.UserInfo(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(trace) != 8 || trace[3] != "d before" {
+		t.Errorf("derived trace = %q", trace)
+	}
+
+	trace = nil
+	if _, err := c.UserInfo(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(trace) != 6 {
+		t.Errorf("middleware added to a derived client leaked into the parent: %q", trace)
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	srv, reqs := formServer(t)
+
+	stub := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (*CallResponse, error) {
+			if call.Method != "userinfo" {
+				return next(ctx, call)
+			}
+			return &CallResponse{HTTPStatus: http.StatusOK, Raw: json.RawMessage(`{"result":0,"email":"stub@example.com"}`)}, nil
+		}
+	}
+	c := NewClient(srv.URL, WithRateLimit(60000), WithMiddleware(stub))
+	info, err := c.UserInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Email != "stub@example.com" {
+		t.Errorf("email = %q, want the stubbed one", info.Email)
+	}
+	if reqs.last() != nil {
+		t.Error("short-circuited call reached the network")
+	}
+
+	apiErr := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (*CallResponse, error) {
+			return &CallResponse{Raw: json.RawMessage(`{"result":2005,"error":"Directory does not exist."}`), Result: 2005}, nil
+		}
+	}
+	c = NewClient(srv.URL, WithRateLimit(60000), WithMiddleware(apiErr))
+	if _, err := c.ListFolder(context.Background(), 1, nil); err == nil || !strings.Contains(err.Error(), "Directory does not exist") {
+		t.Errorf("err = %v, want the stubbed API error", err)
+	}
+}
+
+func TestMiddlewareParams(t *testing.T) {
+	srv, reqs := formServer(t)
+	const secret = "session-token"
+
+	tests := []struct {
+		name   string
+		modify func(call *Call)
+		check  func(t *testing.T, form url.Values)
+	}{
+		{
+			name: "redacted value restored",
+			modify: func(call *Call) {
+				if got := call.Params.Get("auth"); got != redacted {
+					t.Errorf("middleware saw auth = %q", got)
+				}
+			},
+			check: func(t *testing.T, form url.Values) {
+				if form.Get("auth") != secret {
+					t.Errorf("auth = %q, want the real token", form.Get("auth"))
+				}
+			},
+		},
+		{
+			name:   "param changed",
+			modify: func(call *Call) { call.Params.Set("folderid", "99") },
+			check: func(t *testing.T, form url.Values) {
+				if form.Get("folderid") != "99" {
+					t.Errorf("folderid = %q, want 99", form.Get("folderid"))
+				}
+			},
+		},
+		{
+			name:   "replaced secret kept",
+			modify: func(call *Call) { call.Params.Set("auth", "other-token") },
+			check: func(t *testing.T, form url.Values) {
+				if form.Get("auth") != "other-token" {
+					t.Errorf("auth = %q, want the middleware's token", form.Get("auth"))
+				}
+			},
+		},
+		{
+			name:   "removed secret not restored",
+			modify: func(call *Call) { call.Params.Del("auth") },
+			check: func(t *testing.T, form url.Values) {
+				if form.Has("auth") {
+					t.Errorf("auth = %q, want none", form.Get("auth"))
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mw := func(next Handler) Handler {
+				return func(ctx context.Context, call *Call) (*CallResponse, error) {
+					tt.modify(call)
+					return next(ctx, call)
+				}
+			}
+			c := NewClient(srv.URL, WithRateLimit(60000), WithAuthToken(secret), WithMiddleware(mw))
+			if _, err := c.ListFolder(context.Background(), 1, nil); err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, reqs.last())
+		})
+	}
+}
+
+func TestMiddlewareSeesDownloads(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/getfilelink":
+			host := strings.TrimPrefix(srv.URL, "https://")
+			fmt.Fprintf(w, `{"result":0,"path":"/dl/a.txt","hosts":[%q]}`, host)
+		case "/dl/a.txt":
+			w.Header().Set("Content-Type", "text/plain")
+			fmt.Fprint(w, "hello")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var calls []string
+	mw := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (*CallResponse, error) {
+			calls = append(calls, string(call.Kind)+" "+call.Method)
+			if call.Kind == CallDownload && call.URL != srv.URL+"/dl/a.txt" {
+				t.Errorf("download URL = %q", call.URL)
+			}
+			return next(ctx, call)
+		}
+	}
+	c := NewClient(srv.URL, WithHTTPClient(srv.Client()), WithRateLimit(60000), WithMiddleware(mw))
+	body, err := c.Download(context.Background(), 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello" {
+		t.Errorf("content = %q", data)
+	}
+	want := []string{"api getfilelink", "download download"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+}
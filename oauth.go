@@ -2,11 +2,26 @@ package pcloud
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"golang.org/x/oauth2"
 )
 
+const (
+	LocationUS = 1
+	LocationEU = 2
+)
+
 var Endpoint = oauth2.Endpoint{
 	AuthURL:  BaseURLUS + "/oauth2_authorize",
 	TokenURL: BaseURLUS + "/oauth2_token",
@@ -22,22 +37,211 @@ type exchangeResponse struct {
 	AccessToken string `json:"access_token"`
 	TokenType   string `json:"token_type"`
 	UserID      uint64 `json:"userid"`
+	LocationID  int    `json:"locationid"`
+	Hostname    string `json:"hostname"`
+}
+
+type AuthorizeResult struct {
+	Token      *oauth2.Token
+	UserID     uint64
+	LocationID int
+	BaseURL    string
+}
+
+func BaseURLForLocation(locationID int) string {
+	if locationID == LocationEU {
+		return BaseURLEU
+	}
+	return BaseURLUS
 }
 
 func (c *Client) ExchangeCode(ctx context.Context, cfg *oauth2.Config, code string) (*oauth2.Token, error) {
+	result, err := c.exchangeCode(ctx, cfg, code, "")
+	if err != nil {
+		return nil, err
+	}
+	return result.Token, nil
+}
+
+func (c *Client) exchangeCode(ctx context.Context, cfg *oauth2.Config, code, hostname string) (*AuthorizeResult, error) {
 	params := url.Values{
 		"client_id":     {cfg.ClientID},
 		"client_secret": {cfg.ClientSecret},
 		"code":          {code},
 	}
 
+	baseURL := c.BaseURL()
+	if cfg.Endpoint.TokenURL != "" {
+		baseURL = strings.TrimSuffix(cfg.Endpoint.TokenURL, "/oauth2_token")
+	}
+	if hostname != "" {
+		if !isAPIHost(hostname) {
+			return nil, fmt.Errorf("oauth2: unknown api host %q", hostname)
+		}
+		baseURL = "https://" + hostname
+	}
+
 	var resp exchangeResponse
-	if err := c.do(ctx, "oauth2_token", params, &resp); err != nil {
+	if err := c.withBaseURL(baseURL).do(ctx, "oauth2_token", params, &resp); err != nil {
 		return nil, err
 	}
 
-	return &oauth2.Token{
+	result := &AuthorizeResult{
+		UserID:     resp.UserID,
+		LocationID: resp.LocationID,
+		BaseURL:    baseURL,
+	}
+	if isAPIHost(resp.Hostname) {
+		result.BaseURL = "https://" + resp.Hostname
+	} else if resp.LocationID != 0 {
+		result.BaseURL = BaseURLForLocation(resp.LocationID)
+	}
+
+	token := &oauth2.Token{
 		AccessToken: resp.AccessToken,
 		TokenType:   resp.TokenType,
-	}, nil
+	}
+	result.Token = token.WithExtra(map[string]any{
+		"userid":     resp.UserID,
+		"locationid": resp.LocationID,
+		"hostname":   strings.TrimPrefix(result.BaseURL, "https://"),
+	})
+	c.mu.Lock()
+	if c.autoRegion {
+		c.baseURL = result.BaseURL
+		c.autoRegion = false
+	}
+	c.mu.Unlock()
+	return result, nil
+}
+
+type authorizeCallback struct {
+	code     string
+	hostname string
+	err      error
+}
+
+func Authorize(ctx context.Context, cfg *oauth2.Config, openURL func(authURL string) error) (*AuthorizeResult, error) {
+	return NewClient("").Authorize(ctx, cfg, openURL)
+}
+
+func (c *Client) Authorize(ctx context.Context, cfg *oauth2.Config, openURL func(authURL string) error) (*AuthorizeResult, error) {
+	addr := "127.0.0.1:0"
+	path := "/"
+	if cfg.RedirectURL != "" {
+		u, err := url.Parse(cfg.RedirectURL)
+		if err != nil {
+			return nil, fmt.Errorf("redirect url: %w", err)
+		}
+		addr = u.Host
+		if u.Path != "" {
+			path = u.Path
+		}
+	}
+
+	var lc net.ListenConfig
+	listener, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+
+	state, err := randomState()
+	if err != nil {
+		return nil, err
+	}
+
+	authCfg := *cfg
+	if authCfg.Endpoint.AuthURL == "" {
+		authCfg.Endpoint = Endpoint
+	}
+	if authCfg.RedirectURL == "" {
+		authCfg.RedirectURL = "http://" + listener.Addr().String() + "/"
+	}
+
+	callbacks := make(chan authorizeCallback, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if !query.Has("code") && !query.Has("error") {
+			http.NotFound(w, r)
+			return
+		}
+		cb := parseAuthorizeCallback(query, state)
+		if errors.Is(cb.err, errStateMismatch) {
+			// Not our redirect; keep waiting for the one that is.
+			http.Error(w, cb.err.Error(), http.StatusBadRequest)
+			return
+		}
+		if cb.err != nil {
+			http.Error(w, cb.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Authorization complete. You can close this window.")
+		}
+		select {
+		case callbacks <- cb:
+		default:
+		}
+	})
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	if err := openURL(authCfg.AuthCodeURL(state)); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case cb := <-callbacks:
+		if cb.err != nil {
+			return nil, cb.err
+		}
+		return c.exchangeCode(ctx, &authCfg, cb.code, cb.hostname)
+	}
+}
+
+var errStateMismatch = errors.New("oauth2: state mismatch")
+
+func parseAuthorizeCallback(query url.Values, state string) authorizeCallback {
+	if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(state)) != 1 {
+		return authorizeCallback{err: errStateMismatch}
+	}
+	if e := query.Get("error"); e != "" {
+		return authorizeCallback{err: fmt.Errorf("oauth2: %s", e)}
+	}
+
+	code := query.Get("code")
+	if code == "" {
+		return authorizeCallback{err: errors.New("oauth2: missing code")}
+	}
+
+	hostname := query.Get("hostname")
+	if hostname == "" {
+		if id, err := strconv.Atoi(query.Get("locationid")); err == nil {
+			hostname = strings.TrimPrefix(BaseURLForLocation(id), "https://")
+		}
+	}
+	return authorizeCallback{code: code, hostname: hostname}
+}
+
+// isAPIHost reports whether host is one of pCloud's regional API hosts,
+// the only places client secrets and tokens are sent to.
+func isAPIHost(host string) bool {
+	for _, baseURL := range []string{BaseURLUS, BaseURLEU} {
+		if host == strings.TrimPrefix(baseURL, "https://") {
+			return true
+		}
+	}
+	return false
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
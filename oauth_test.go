@@ -0,0 +1,114 @@
+package pcloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// authorizeWith runs Authorize, hitting the loopback callback once per
+// query in callbacks. "STATE" in a query is replaced with the real state.
+func authorizeWith(t *testing.T, tokenURL string, callbacks ...string) (*AuthorizeResult, error) {
+	t.Helper()
+	cfg := &oauth2.Config{
+		ClientID:     "id",
+		ClientSecret: "secret",
+		Endpoint:     oauth2.Endpoint{AuthURL: "https://example.com/auth", TokenURL: tokenURL},
+	}
+	openURL := func(authURL string) error {
+		u, err := url.Parse(authURL)
+		if err != nil {
+			return err
+		}
+		redirect := u.Query().Get("redirect_uri")
+		state := u.Query().Get("state")
+		for _, query := range callbacks {
+			resp, err := http.Get(redirect + "?" + strings.ReplaceAll(query, "STATE", state))
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+		}
+		return nil
+	}
+	return NewClient("", WithRateLimit(60000)).Authorize(context.Background(), cfg, openURL)
+}
+
+func TestAuthorizeIgnoresForeignState(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.URL.Path != "/oauth2_token" || r.Form.Get("code") != "good" {
+			t.Errorf("unexpected call %s %v", r.URL.Path, r.Form)
+		}
+		fmt.Fprint(w, `{"result":0,"access_token":"tok","userid":7}`)
+	}))
+	defer srv.Close()
+
+	result, err := authorizeWith(t, srv.URL+"/oauth2_token",
+		"code=evil&state=wrong",
+		"error=access_denied&state=wrong",
+		"code=good&state=STATE",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Token.AccessToken != "tok" || result.UserID != 7 {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestAuthorizeRejectsUnknownHost(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected call %s", r.URL.Path)
+	}))
+	defer srv.Close()
+
+	_, err := authorizeWith(t, srv.URL+"/oauth2_token", "code=good&state=STATE&hostname=evil.example.com")
+	if err == nil || !strings.Contains(err.Error(), "unknown api host") {
+		t.Fatalf("err = %v, want unknown api host", err)
+	}
+}
+
+func TestAuthorizeDefaultEndpoint(t *testing.T) {
+	var authURL string
+	openURL := func(u string) error {
+		authURL = u
+		return errors.New("stop")
+	}
+	_, err := NewClient("").Authorize(context.Background(), &oauth2.Config{ClientID: "id"}, openURL)
+	if err == nil || err.Error() != "stop" {
+		t.Fatalf("err = %v", err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.Scheme + "://" + u.Host + u.Path; got != Endpoint.AuthURL {
+		t.Errorf("auth URL = %s, want %s", authURL, Endpoint.AuthURL)
+	}
+	if u.Query().Get("client_id") != "id" || u.Query().Get("state") == "" {
+		t.Errorf("auth URL query = %v", u.Query())
+	}
+}
+
+func TestIsAPIHost(t *testing.T) {
+	for host, want := range map[string]bool{
+		"api.pcloud.com":          true,
+		"eapi.pcloud.com":         true,
+		"":                        false,
+		"evil.example.com":        false,
+		"api.pcloud.com.evil.com": false,
+		"api.pcloud.com:8080":     false,
+	} {
+		if got := isAPIHost(host); got != want {
+			t.Errorf("isAPIHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package otel_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/yanmhlv/pcloud"
+	pcloudotel "github.com/yanmhlv/pcloud/otel"
+	"go.opentelemetry.io/otel"
+)
+
+func ExampleMiddleware() {
+	mw, err := pcloudotel.Middleware()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, span := otel.Tracer("backup").Start(context.Background(), "restore-report")
+	defer span.End()
+
+	c := pcloud.NewClient(pcloud.BaseURLUS, pcloud.WithMiddleware(mw))
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	rc, err := c.DownloadByPath(ctx, "/reports/latest.csv", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rc.Close()
+
+	n, err := io.Copy(os.Stdout, rc)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("\n%d bytes\n", n)
+}
@@ -0,0 +1,213 @@
+// Package otel instruments a pcloud.Client with OpenTelemetry tracing and
+// metrics.
+//
+// Middleware returns a pcloud.Middleware that starts a client span for every
+// API call and file download, named after the pCloud method (listfolder,
+// uploadfile, download, ...). Spans carry the result code, bytes
+// transferred, throttling retries and the host that served the call, and
+// the trace context is injected into the request headers. Spans are children
+// of the span in the context passed to the client, so the calls made by
+// Upload, Download or Walk nest under the caller's span. Download spans end
+// when the returned body is closed.
+//
+// Two histograms are recorded per call: pcloud.client.duration in seconds
+// and pcloud.client.throughput in bytes per second.
+//
+//	mw, err := otel.Middleware()
+//	if err != nil {
+//	    return err
+//	}
+//	c := pcloud.NewClient(pcloud.BaseURLUS, pcloud.WithMiddleware(mw))
+//
+// This package lives in its own module so the core pcloud package does not
+// depend on OpenTelemetry.
+package otel
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yanmhlv/pcloud"
+	otelapi "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/yanmhlv/pcloud/otel"
+
+const (
+	attrMethod  = attribute.Key("pcloud.method")
+	attrKind    = attribute.Key("pcloud.call.kind")
+	attrResult  = attribute.Key("pcloud.result")
+	attrBytes   = attribute.Key("pcloud.bytes")
+	attrRetries = attribute.Key("pcloud.retries")
+	attrHost    = attribute.Key("server.address")
+	attrStatus  = attribute.Key("http.response.status_code")
+)
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	meterProvider  metric.MeterProvider
+	propagator     propagation.TextMapPropagator
+}
+
+type Option func(*config)
+
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = tp
+	}
+}
+
+func WithMeterProvider(mp metric.MeterProvider) Option {
+	return func(c *config) {
+		c.meterProvider = mp
+	}
+}
+
+func WithPropagator(p propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagator = p
+	}
+}
+
+type instruments struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	duration   metric.Float64Histogram
+	throughput metric.Float64Histogram
+}
+
+// Middleware returns a pcloud.Middleware that traces and measures calls. The
+// global tracer provider, meter provider and propagator are used unless
+// overridden by options.
+func Middleware(opts ...Option) (pcloud.Middleware, error) {
+	cfg := config{
+		tracerProvider: otelapi.GetTracerProvider(),
+		meterProvider:  otelapi.GetMeterProvider(),
+		propagator:     otelapi.GetTextMapPropagator(),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	meter := cfg.meterProvider.Meter(instrumentationName)
+	duration, err := meter.Float64Histogram("pcloud.client.duration",
+		metric.WithDescription("Duration of pCloud API calls and downloads."),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	throughput, err := meter.Float64Histogram("pcloud.client.throughput",
+		metric.WithDescription("Bytes transferred per second by pCloud API calls and downloads."),
+		metric.WithUnit("By/s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	inst := &instruments{
+		tracer:     cfg.tracerProvider.Tracer(instrumentationName),
+		propagator: cfg.propagator,
+		duration:   duration,
+		throughput: throughput,
+	}
+	return inst.middleware, nil
+}
+
+func (inst *instruments) middleware(next pcloud.Handler) pcloud.Handler {
+	return func(ctx context.Context, call *pcloud.Call) (*pcloud.CallResponse, error) {
+		ctx, span := inst.tracer.Start(ctx, call.Method,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrMethod.String(call.Method), attrKind.String(string(call.Kind))),
+		)
+		inst.propagator.Inject(ctx, propagation.HeaderCarrier(call.Header))
+
+		start := time.Now()
+		resp, err := next(ctx, call)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			inst.record(ctx, call, time.Since(start), 0)
+			span.End()
+			return nil, err
+		}
+
+		span.SetAttributes(
+			attrResult.Int(resp.Result),
+			attrRetries.Int(resp.Retries),
+			attrHost.String(resp.Host),
+			attrStatus.Int(resp.HTTPStatus),
+		)
+		if resp.Result != 0 {
+			span.SetStatus(codes.Error, "pcloud result "+strconv.Itoa(resp.Result))
+		}
+
+		if resp.Body != nil {
+			resp.Body = &tracedBody{
+				ReadCloser: resp.Body,
+				ctx:        ctx,
+				span:       span,
+				inst:       inst,
+				call:       call,
+				start:      start,
+			}
+			return resp, nil
+		}
+
+		n := resp.ContentLength
+		if call.ContentLength > 0 {
+			n += call.ContentLength
+		}
+		span.SetAttributes(attrBytes.Int64(n))
+		inst.record(ctx, call, time.Since(start), n)
+		span.End()
+		return resp, nil
+	}
+}
+
+func (inst *instruments) record(ctx context.Context, call *pcloud.Call, elapsed time.Duration, n int64) {
+	attrs := metric.WithAttributes(attrMethod.String(call.Method), attrKind.String(string(call.Kind)))
+	inst.duration.Record(ctx, elapsed.Seconds(), attrs)
+	if n > 0 && elapsed > 0 {
+		inst.throughput.Record(ctx, float64(n)/elapsed.Seconds(), attrs)
+	}
+}
+
+type tracedBody struct {
+	io.ReadCloser
+	ctx   context.Context
+	span  trace.Span
+	inst  *instruments
+	call  *pcloud.Call
+	start time.Time
+	n     int64
+	once  sync.Once
+}
+
+func (b *tracedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if err != nil && err != io.EOF {
+		b.span.RecordError(err)
+		b.span.SetStatus(codes.Error, err.Error())
+	}
+	return n, err
+}
+
+func (b *tracedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.span.SetAttributes(attrBytes.Int64(b.n))
+		b.inst.record(b.ctx, b.call, time.Since(b.start), b.n)
+		b.span.End()
+	})
+	return err
+}
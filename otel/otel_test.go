@@ -0,0 +1,228 @@
+package otel_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/yanmhlv/pcloud"
+	pcloudotel "github.com/yanmhlv/pcloud/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type testTelemetry struct {
+	spans  *tracetest.SpanRecorder
+	reader *sdkmetric.ManualReader
+	mw     pcloud.Middleware
+}
+
+func newTestTelemetry(t *testing.T) *testTelemetry {
+	t.Helper()
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	mw, err := pcloudotel.Middleware(
+		pcloudotel.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+		pcloudotel.WithMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testTelemetry{spans: spans, reader: reader, mw: mw}
+}
+
+// histograms returns the number of data points recorded per histogram.
+func (tt *testTelemetry) histograms(t *testing.T) map[string]uint64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := tt.reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]uint64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			h, ok := m.Data.(metricdata.Histogram[float64])
+			if !ok {
+				t.Fatalf("%s: unexpected data %T", m.Name, m.Data)
+			}
+			for _, dp := range h.DataPoints {
+				counts[m.Name] += dp.Count
+			}
+		}
+	}
+	return counts
+}
+
+func newTestCall(method string, kind pcloud.CallKind) *pcloud.Call {
+	return &pcloud.Call{Method: method, Kind: kind, Header: http.Header{}}
+}
+
+func spanAttrs(s sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range s.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestMiddlewareSpan(t *testing.T) {
+	tt := newTestTelemetry(t)
+	h := tt.mw(func(_ context.Context, call *pcloud.Call) (*pcloud.CallResponse, error) {
+		if call.Header.Get("Traceparent") != "" {
+			t.Error("no propagator configured, but traceparent was injected")
+		}
+		return &pcloud.CallResponse{
+			HTTPStatus:    http.StatusOK,
+			Host:          "api.pcloud.com",
+			Retries:       1,
+			ContentLength: 42,
+		}, nil
+	})
+
+	call := newTestCall("listfolder", pcloud.CallAPI)
+	call.ContentLength = 8
+	if _, err := h(context.Background(), call); err != nil {
+		t.Fatal(err)
+	}
+
+	ended := tt.spans.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("ended spans = %d, want 1", len(ended))
+	}
+	s := ended[0]
+	if s.Name() != "listfolder" {
+		t.Errorf("span name = %q", s.Name())
+	}
+	if s.SpanKind() != trace.SpanKindClient {
+		t.Errorf("span kind = %v", s.SpanKind())
+	}
+	if s.Status().Code != codes.Unset {
+		t.Errorf("status = %v", s.Status())
+	}
+
+	attrs := spanAttrs(s)
+	want := map[attribute.Key]attribute.Value{
+		"pcloud.method":             attribute.StringValue("listfolder"),
+		"pcloud.call.kind":          attribute.StringValue("api"),
+		"pcloud.result":             attribute.IntValue(0),
+		"pcloud.retries":            attribute.IntValue(1),
+		"server.address":            attribute.StringValue("api.pcloud.com"),
+		"http.response.status_code": attribute.IntValue(http.StatusOK),
+		"pcloud.bytes":              attribute.Int64Value(50),
+	}
+	for k, v := range want {
+		if attrs[k] != v {
+			t.Errorf("%s = %v, want %v", k, attrs[k].Emit(), v.Emit())
+		}
+	}
+
+	got := tt.histograms(t)
+	if got["pcloud.client.duration"] != 1 || got["pcloud.client.throughput"] != 1 {
+		t.Errorf("histograms = %v", got)
+	}
+}
+
+func TestMiddlewareResultError(t *testing.T) {
+	tt := newTestTelemetry(t)
+	h := tt.mw(func(context.Context, *pcloud.Call) (*pcloud.CallResponse, error) {
+		return &pcloud.CallResponse{HTTPStatus: http.StatusOK, Result: 2005}, nil
+	})
+	if _, err := h(context.Background(), newTestCall("stat", pcloud.CallAPI)); err != nil {
+		t.Fatal(err)
+	}
+
+	s := tt.spans.Ended()[0]
+	if s.Status().Code != codes.Error || s.Status().Description != "pcloud result 2005" {
+		t.Errorf("status = %v", s.Status())
+	}
+	if v := spanAttrs(s)["pcloud.result"]; v != attribute.IntValue(2005) {
+		t.Errorf("pcloud.result = %v", v.Emit())
+	}
+
+	got := tt.histograms(t)
+	if got["pcloud.client.duration"] != 1 || got["pcloud.client.throughput"] != 0 {
+		t.Errorf("histograms = %v", got)
+	}
+}
+
+func TestMiddlewareTransportError(t *testing.T) {
+	tt := newTestTelemetry(t)
+	failure := errors.New("connection refused")
+	h := tt.mw(func(context.Context, *pcloud.Call) (*pcloud.CallResponse, error) {
+		return nil, failure
+	})
+	if _, err := h(context.Background(), newTestCall("userinfo", pcloud.CallAPI)); !errors.Is(err, failure) {
+		t.Fatalf("err = %v", err)
+	}
+
+	s := tt.spans.Ended()[0]
+	if s.Status().Code != codes.Error {
+		t.Errorf("status = %v", s.Status())
+	}
+	if len(s.Events()) != 1 || s.Events()[0].Name != "exception" {
+		t.Errorf("events = %v", s.Events())
+	}
+	if got := tt.histograms(t); got["pcloud.client.duration"] != 1 {
+		t.Errorf("histograms = %v", got)
+	}
+}
+
+func TestMiddlewareDownloadEndsOnClose(t *testing.T) {
+	tt := newTestTelemetry(t)
+	h := tt.mw(func(context.Context, *pcloud.Call) (*pcloud.CallResponse, error) {
+		return &pcloud.CallResponse{
+			HTTPStatus: http.StatusOK,
+			Host:       "c1.pcloud.com",
+			Body:       io.NopCloser(strings.NewReader("file content")),
+		}, nil
+	})
+
+	resp, err := h(context.Background(), newTestCall("download", pcloud.CallDownload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tt.spans.Ended()) != 0 {
+		t.Fatal("download span ended before the body was closed")
+	}
+	if started := tt.spans.Started(); len(started) != 1 || started[0].Name() != "download" {
+		t.Fatalf("started spans = %v", started)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "file content" {
+		t.Errorf("body = %q", data)
+	}
+	if len(tt.spans.Ended()) != 0 {
+		t.Fatal("download span ended before the body was closed")
+	}
+
+	if err := resp.Body.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := resp.Body.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ended := tt.spans.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("ended spans = %d, want 1", len(ended))
+	}
+	if v := spanAttrs(ended[0])["pcloud.bytes"]; v != attribute.Int64Value(int64(len("file content"))) {
+		t.Errorf("pcloud.bytes = %v", v.Emit())
+	}
+
+	got := tt.histograms(t)
+	if got["pcloud.client.duration"] != 1 || got["pcloud.client.throughput"] != 1 {
+		t.Errorf("histograms = %v", got)
+	}
+}
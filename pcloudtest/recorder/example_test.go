@@ -0,0 +1,33 @@
+package recorder_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/yanmhlv/pcloud"
+	"github.com/yanmhlv/pcloud/pcloudtest/recorder"
+)
+
+func ExampleNew() {
+	ctx := context.Background()
+	rec, err := recorder.New("testdata/walk.json", recorder.ModeAuto,
+		recorder.WithIgnoredParams("mtime", "ctime"),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer rec.Stop()
+
+	c := pcloud.NewClient(pcloud.BaseURLUS, pcloud.WithHTTPClient(rec.Client()))
+	if rec.Mode() == recorder.ModeRecord {
+		c.Login(ctx, "user@example.com", "password")
+	}
+
+	for item, err := range c.Walk(ctx, 0) {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(item.Name)
+	}
+}
@@ -0,0 +1,223 @@
+package recorder_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yanmhlv/pcloud"
+	"github.com/yanmhlv/pcloud/pcloudtest/recorder"
+)
+
+const secret = "s3cr3t-value"
+
+func apiServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseMultipartForm(1 << 20)
+		w.Header().Set("Content-Type", "application/json")
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: secret})
+		switch r.URL.Path {
+		case "/userinfo":
+			fmt.Fprintf(w, `{"result":0,"email":"user@example.com","auth":%q,"token":%q}`, secret, secret)
+		case "/listfolder":
+			fmt.Fprintf(w, `{"result":0,"metadata":{"folderid":%s,"name":"folder-%s","isfolder":true}}`, r.Form.Get("folderid"), r.Form.Get("folderid"))
+		case "/uploadfile":
+			fmt.Fprintf(w, `{"result":0,"metadata":[{"name":%q}]}`, r.MultipartForm.File["file"][0].Filename)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func record(t *testing.T, srv *httptest.Server, path string, calls func(c *pcloud.Client)) {
+	t.Helper()
+	rec, err := recorder.New(path, recorder.ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls(pcloud.NewClient(srv.URL, pcloud.WithHTTPClient(rec.Client()), pcloud.WithAuthToken(secret), pcloud.WithRateLimit(60000)))
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func replayClient(t *testing.T, baseURL, path string, opts ...recorder.Option) *pcloud.Client {
+	t.Helper()
+	rec, err := recorder.New(path, recorder.ModeReplay, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pcloud.NewClient(baseURL, pcloud.WithHTTPClient(rec.Client()), pcloud.WithRateLimit(60000))
+}
+
+func TestRecordScrubsCredentials(t *testing.T) {
+	srv := apiServer(t)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	record(t, srv, path, func(c *pcloud.Client) {
+		if _, err := c.UserInfo(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte(secret)) {
+		t.Errorf("secret written to cassette:\n%s", data)
+	}
+	if bytes.Contains(data, []byte("Set-Cookie")) {
+		t.Errorf("Set-Cookie written to cassette:\n%s", data)
+	}
+	if !bytes.Contains(data, []byte("user@example.com")) {
+		t.Errorf("response body not recorded:\n%s", data)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	srv := apiServer(t)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	record(t, srv, path, func(c *pcloud.Client) {
+		ctx := context.Background()
+		for _, id := range []uint64{1, 2} {
+			if _, err := c.ListFolder(ctx, id, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := c.Upload(ctx, 1, "a.txt", strings.NewReader("hello"), nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+	srv.Close()
+
+	ctx := context.Background()
+	t.Run("strict", func(t *testing.T) {
+		c := replayClient(t, srv.URL, path)
+		folder, err := c.ListFolder(ctx, 2, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if folder.Name != "folder-2" {
+			t.Errorf("name = %q, want folder-2", folder.Name)
+		}
+		if _, err := c.ListFolder(ctx, 3, nil); err == nil {
+			t.Error("unrecorded folderid replayed")
+		}
+		if _, err := c.ListFolder(ctx, 2, nil); err == nil {
+			t.Error("interaction replayed twice")
+		}
+		meta, err := c.Upload(ctx, 1, "a.txt", strings.NewReader("other content"), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if meta.Name != "a.txt" {
+			t.Errorf("uploaded name = %q", meta.Name)
+		}
+		if _, err := c.Upload(ctx, 1, "b.txt", strings.NewReader("hello"), nil); err == nil {
+			t.Error("upload with another filename replayed")
+		}
+	})
+	t.Run("ignored params", func(t *testing.T) {
+		c := replayClient(t, srv.URL, path, recorder.WithIgnoredParams("folderid"))
+		folder, err := c.ListFolder(ctx, 9, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if folder.Name != "folder-1" {
+			t.Errorf("name = %q, want folder-1", folder.Name)
+		}
+	})
+	t.Run("lenient", func(t *testing.T) {
+		c := replayClient(t, srv.URL, path, recorder.WithMatching(recorder.MatchLenient))
+		for _, want := range []string{"folder-1", "folder-2"} {
+			folder, err := c.ListFolder(ctx, 42, &pcloud.ListFolderOpts{Recursive: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if folder.Name != want {
+				t.Errorf("name = %q, want %q", folder.Name, want)
+			}
+		}
+		if _, err := c.ListFolder(ctx, 42, nil); err == nil {
+			t.Error("more interactions replayed than recorded")
+		}
+	})
+}
+
+func TestModeAuto(t *testing.T) {
+	srv := apiServer(t)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	rec, err := recorder.New(path, recorder.ModeAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Mode() != recorder.ModeRecord {
+		t.Fatalf("mode = %v without cassette, want ModeRecord", rec.Mode())
+	}
+	c := pcloud.NewClient(srv.URL, pcloud.WithHTTPClient(rec.Client()), pcloud.WithRateLimit(60000))
+	if _, err := c.ListFolder(context.Background(), 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err = recorder.New(path, recorder.ModeAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Mode() != recorder.ModeReplay {
+		t.Errorf("mode = %v with cassette, want ModeReplay", rec.Mode())
+	}
+
+	if _, err := recorder.New(filepath.Join(t.TempDir(), "missing.json"), recorder.ModeReplay); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("replay without cassette: err = %v", err)
+	}
+}
+
+func TestRoundTripLeavesRequestAlone(t *testing.T) {
+	var got url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		got = r.PostForm
+		fmt.Fprint(w, `{"result":0}`)
+	}))
+	defer srv.Close()
+
+	rec, err := recorder.New(filepath.Join(t.TempDir(), "cassette.json"), recorder.ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := io.NopCloser(strings.NewReader("auth=" + secret + "&folderid=1"))
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/listfolder", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := rec.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if req.Body != body {
+		t.Error("RoundTrip replaced req.Body")
+	}
+	if got.Get("auth") != secret || got.Get("folderid") != "1" {
+		t.Errorf("server got %v", got)
+	}
+}
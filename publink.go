@@ -2,29 +2,108 @@ package pcloud
 
 import (
 	"context"
+	"encoding/json"
 	"net/url"
 	"strconv"
+	"time"
+)
+
+type PublicLinkKind string
+
+const (
+	PublicLinkFile   PublicLinkKind = "file"
+	PublicLinkFolder PublicLinkKind = "folder"
+	PublicLinkTree   PublicLinkKind = "tree"
 )
 
 type PublicLink struct {
 	Error
-	LinkID    uint64   `json:"linkid"`
-	Code      string   `json:"code"`
-	Link      string   `json:"link"`
-	Created   Time     `json:"created"`
-	Modified  Time     `json:"modified"`
-	Traffic   uint64   `json:"traffic"`
-	Downloads uint64   `json:"downloads"`
-	Metadata  Metadata `json:"metadata"`
-	ShortLink string   `json:"shortlink,omitempty"`
-	ShortCode string   `json:"shortcode,omitempty"`
+	LinkID       uint64         `json:"linkid"`
+	Code         string         `json:"code"`
+	Link         string         `json:"link"`
+	Created      Time           `json:"created"`
+	Modified     Time           `json:"modified"`
+	Traffic      uint64         `json:"traffic"`
+	Downloads    uint64         `json:"downloads"`
+	Expires      Time           `json:"expires,omitempty"`
+	MaxTraffic   uint64         `json:"maxtraffic,omitempty"`
+	MaxDownloads uint64         `json:"maxdownloads,omitempty"`
+	HasPassword  bool           `json:"haspassword,omitempty"`
+	Metadata     Metadata       `json:"metadata"`
+	ShortLink    string         `json:"shortlink,omitempty"`
+	ShortCode    string         `json:"shortcode,omitempty"`
+	Kind         PublicLinkKind `json:"-"`
+}
+
+// UnmarshalJSON sets Kind from the link metadata. Tree links share a
+// virtual folder that has no folderid at all, unlike a link to the root
+// folder, whose folderid is 0.
+func (l *PublicLink) UnmarshalJSON(data []byte) error {
+	type publicLink PublicLink
+	var raw publicLink
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	var shape struct {
+		Metadata struct {
+			FolderID *uint64 `json:"folderid"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &shape); err != nil {
+		return err
+	}
+	*l = PublicLink(raw)
+	if l.Result != 0 {
+		return nil
+	}
+	switch {
+	case !l.Metadata.IsFolder:
+		l.Kind = PublicLinkFile
+	case shape.Metadata.FolderID == nil:
+		l.Kind = PublicLinkTree
+	default:
+		l.Kind = PublicLinkFolder
+	}
+	return nil
 }
 
 type PublicLinkOpts struct {
-	MaxDownloads uint64
-	MaxTraffic   uint64
-	ExpireTime   int64
-	ShortLink    bool
+	MaxDownloads      uint64
+	MaxTraffic        uint64
+	ExpireTime        int64
+	ShortLink         bool
+	Password          string
+	ClearMaxDownloads bool
+	ClearMaxTraffic   bool
+	ClearExpire       bool
+	ClearPassword     bool
+}
+
+type PublicLinkStatsOpts struct {
+	From time.Time
+	To   time.Time
+}
+
+type PublicLinkStat struct {
+	Date      Time   `json:"dt"`
+	Downloads uint64 `json:"downloads"`
+	Traffic   uint64 `json:"traffic"`
+	Views     uint64 `json:"views"`
+}
+
+type PublicLinkStats struct {
+	Error
+	Stats []PublicLinkStat `json:"stats"`
+}
+
+func (s *PublicLinkStats) Totals() PublicLinkStat {
+	var total PublicLinkStat
+	for _, stat := range s.Stats {
+		total.Downloads += stat.Downloads
+		total.Traffic += stat.Traffic
+		total.Views += stat.Views
+	}
+	return total
 }
 
 type listPublicLinksResponse struct {
@@ -48,6 +127,21 @@ func applyPublicLinkOpts(params url.Values, opts *PublicLinkOpts) {
 	if opts.ShortLink {
 		params.Set("shortlink", "1")
 	}
+	if opts.Password != "" {
+		params.Set("linkpassword", opts.Password)
+	}
+	if opts.ClearMaxDownloads {
+		params.Set("maxdownloads", "0")
+	}
+	if opts.ClearMaxTraffic {
+		params.Set("maxtraffic", "0")
+	}
+	if opts.ClearExpire {
+		params.Set("deleteexpire", "1")
+	}
+	if opts.ClearPassword {
+		params.Set("deletepassword", "1")
+	}
 }
 
 func (c *Client) CreateFilePublicLink(ctx context.Context, fileID uint64, opts *PublicLinkOpts) (*PublicLink, error) {
@@ -102,6 +196,26 @@ func (c *Client) CreateFolderPublicLinkByPath(ctx context.Context, path string,
 	return &resp, nil
 }
 
+func (c *Client) CreateTreePublicLink(ctx context.Context, name string, folderIDs, fileIDs []uint64, opts *PublicLinkOpts) (*PublicLink, error) {
+	params := url.Values{
+		"name": {name},
+	}
+	if len(folderIDs) > 0 {
+		params.Set("folderids", joinIDs(folderIDs))
+	}
+	if len(fileIDs) > 0 {
+		params.Set("fileids", joinIDs(fileIDs))
+	}
+	applyPublicLinkOpts(params, opts)
+
+	var resp PublicLink
+	if err := c.do(ctx, "gettreepublink", params, &resp); err != nil {
+		return nil, err
+	}
+	resp.Kind = PublicLinkTree
+	return &resp, nil
+}
+
 func (c *Client) ListPublicLinks(ctx context.Context) ([]PublicLink, error) {
 	var resp listPublicLinksResponse
 	if err := c.do(ctx, "listpublinks", url.Values{}, &resp); err != nil {
@@ -132,6 +246,26 @@ func (c *Client) ChangePublicLink(ctx context.Context, linkID uint64, opts *Publ
 	return &resp, nil
 }
 
+func (c *Client) GetPublicLinkStats(ctx context.Context, linkID uint64, opts *PublicLinkStatsOpts) (*PublicLinkStats, error) {
+	params := url.Values{
+		"linkid": {strconv.FormatUint(linkID, 10)},
+	}
+	if opts != nil {
+		if !opts.From.IsZero() {
+			params.Set("datefrom", strconv.FormatInt(opts.From.Unix(), 10))
+		}
+		if !opts.To.IsZero() {
+			params.Set("dateto", strconv.FormatInt(opts.To.Unix(), 10))
+		}
+	}
+
+	var resp PublicLinkStats
+	if err := c.do(ctx, "getpublinkstats", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
 func (c *Client) GetPublicLinkInfo(ctx context.Context, code string) (*PublicLink, error) {
 	params := url.Values{
 		"code": {code},
@@ -0,0 +1,34 @@
+package publink_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/yanmhlv/pcloud"
+	"github.com/yanmhlv/pcloud/publink"
+)
+
+func ExampleEnforce() {
+	ctx := context.Background()
+	c := pcloud.NewClient(pcloud.BaseURLUS)
+	c.Login(ctx, "user@example.com", "password")
+	defer c.Logout(ctx)
+
+	policy := &publink.Policy{
+		MaxAge:        90 * 24 * time.Hour,
+		MaxTraffic:    10 << 30,
+		RequireExpiry: 30 * 24 * time.Hour,
+		Include: func(link pcloud.PublicLink) bool {
+			return strings.HasPrefix(link.Metadata.Path, "/shared/")
+		},
+	}
+
+	report, err := publink.Enforce(ctx, c, policy, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(report)
+}
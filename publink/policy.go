@@ -0,0 +1,167 @@
+// Package publink enforces lifecycle policies on pCloud public links.
+//
+// A Policy describes the rules every link must satisfy. Enforce lists the
+// account's links, evaluates each one and deletes or changes the links that
+// violate the policy. With dry run enabled nothing is modified and the
+// returned Report describes what would happen:
+//
+//	policy := &publink.Policy{
+//	    MaxAge:        90 * 24 * time.Hour,
+//	    MaxTraffic:    10 << 30,
+//	    RequireExpiry: 30 * 24 * time.Hour,
+//	}
+//	report, _ := publink.Enforce(ctx, c, policy, true)
+//	fmt.Print(report)
+package publink
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yanmhlv/pcloud"
+)
+
+type Action string
+
+const (
+	ActionKeep   Action = "keep"
+	ActionChange Action = "change"
+	ActionDelete Action = "delete"
+)
+
+type Policy struct {
+	MaxAge        time.Duration
+	MaxTraffic    uint64
+	MaxDownloads  uint64
+	RequireExpiry time.Duration
+	Include       func(link pcloud.PublicLink) bool
+}
+
+type Finding struct {
+	Link    pcloud.PublicLink
+	Age     time.Duration
+	Action  Action
+	Reasons []string
+	Changes *pcloud.PublicLinkOpts
+	Err     error
+}
+
+type Report struct {
+	DryRun   bool
+	Findings []Finding
+	Kept     int
+	Changed  int
+	Deleted  int
+	Failed   int
+}
+
+func (r *Report) String() string {
+	var sb strings.Builder
+	for _, f := range r.Findings {
+		target := f.Link.Metadata.Path
+		if target == "" {
+			target = f.Link.Metadata.Name
+		}
+		fmt.Fprintf(&sb, "%-6s link=%d code=%s kind=%s target=%q age=%dd traffic=%d downloads=%d",
+			f.Action, f.Link.LinkID, f.Link.Code, f.Link.Kind, target,
+			int(f.Age.Hours()/24), f.Link.Traffic, f.Link.Downloads)
+		if len(f.Reasons) > 0 {
+			fmt.Fprintf(&sb, " reasons=%q", strings.Join(f.Reasons, "; "))
+		}
+		if f.Err != nil {
+			fmt.Fprintf(&sb, " error=%q", f.Err)
+		}
+		sb.WriteByte('\n')
+	}
+	fmt.Fprintf(&sb, "kept: %d, changed: %d, deleted: %d, failed: %d\n", r.Kept, r.Changed, r.Deleted, r.Failed)
+	return sb.String()
+}
+
+func (p *Policy) Evaluate(link pcloud.PublicLink, now time.Time) Finding {
+	f := Finding{
+		Link:   link,
+		Age:    now.Sub(link.Created.Time),
+		Action: ActionKeep,
+	}
+	if p.Include != nil && !p.Include(link) {
+		return f
+	}
+
+	if p.MaxAge > 0 && f.Age > p.MaxAge {
+		f.Action = ActionDelete
+		f.Reasons = append(f.Reasons, fmt.Sprintf("older than %s", p.MaxAge))
+		return f
+	}
+
+	changes := &pcloud.PublicLinkOpts{}
+	if p.MaxTraffic > 0 && (link.MaxTraffic == 0 || link.MaxTraffic > p.MaxTraffic) {
+		if link.Traffic >= p.MaxTraffic {
+			f.Action = ActionDelete
+			f.Reasons = append(f.Reasons, fmt.Sprintf("traffic %d exceeds cap %d", link.Traffic, p.MaxTraffic))
+			return f
+		}
+		changes.MaxTraffic = p.MaxTraffic
+		f.Reasons = append(f.Reasons, fmt.Sprintf("traffic cap set to %d", p.MaxTraffic))
+	}
+	if p.MaxDownloads > 0 && (link.MaxDownloads == 0 || link.MaxDownloads > p.MaxDownloads) {
+		if link.Downloads >= p.MaxDownloads {
+			f.Action = ActionDelete
+			f.Reasons = append(f.Reasons, fmt.Sprintf("downloads %d exceed cap %d", link.Downloads, p.MaxDownloads))
+			return f
+		}
+		changes.MaxDownloads = p.MaxDownloads
+		f.Reasons = append(f.Reasons, fmt.Sprintf("download cap set to %d", p.MaxDownloads))
+	}
+	if p.RequireExpiry > 0 && link.Expires.IsZero() {
+		changes.ExpireTime = now.Add(p.RequireExpiry).Unix()
+		f.Reasons = append(f.Reasons, fmt.Sprintf("no expiry, set to %s", p.RequireExpiry))
+	}
+
+	if len(f.Reasons) > 0 {
+		f.Action = ActionChange
+		f.Changes = changes
+	}
+	return f
+}
+
+// Enforce applies p to every public link of the account. If ctx is
+// cancelled part way, the returned report covers the links handled so far,
+// along with ctx.Err().
+func Enforce(ctx context.Context, c *pcloud.Client, p *Policy, dryRun bool) (*Report, error) {
+	links, err := c.ListPublicLinks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report := &Report{DryRun: dryRun}
+	for _, link := range links {
+		f := p.Evaluate(link, now)
+		if !dryRun {
+			switch f.Action {
+			case ActionDelete:
+				f.Err = c.DeletePublicLink(ctx, link.LinkID)
+			case ActionChange:
+				_, f.Err = c.ChangePublicLink(ctx, link.LinkID, f.Changes)
+			case ActionKeep:
+			}
+		}
+		switch {
+		case f.Err != nil:
+			report.Failed++
+		case f.Action == ActionDelete:
+			report.Deleted++
+		case f.Action == ActionChange:
+			report.Changed++
+		default:
+			report.Kept++
+		}
+		report.Findings = append(report.Findings, f)
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
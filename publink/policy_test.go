@@ -0,0 +1,170 @@
+package publink_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/yanmhlv/pcloud"
+	"github.com/yanmhlv/pcloud/publink"
+)
+
+func TestPolicyEvaluate(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	link := func(age time.Duration, mod func(*pcloud.PublicLink)) pcloud.PublicLink {
+		l := pcloud.PublicLink{LinkID: 1, Created: pcloud.Time{Time: now.Add(-age)}}
+		if mod != nil {
+			mod(&l)
+		}
+		return l
+	}
+
+	tests := []struct {
+		name    string
+		policy  publink.Policy
+		link    pcloud.PublicLink
+		action  publink.Action
+		changes *pcloud.PublicLinkOpts
+	}{
+		{
+			name:   "empty policy keeps",
+			link:   link(400*day, nil),
+			action: publink.ActionKeep,
+		},
+		{
+			name:   "older than max age",
+			policy: publink.Policy{MaxAge: 90 * day},
+			link:   link(91*day, nil),
+			action: publink.ActionDelete,
+		},
+		{
+			name:   "younger than max age",
+			policy: publink.Policy{MaxAge: 90 * day},
+			link:   link(89*day, nil),
+			action: publink.ActionKeep,
+		},
+		{
+			name:   "excluded link is kept",
+			policy: publink.Policy{MaxAge: day, Include: func(pcloud.PublicLink) bool { return false }},
+			link:   link(10*day, nil),
+			action: publink.ActionKeep,
+		},
+		{
+			name:   "traffic over cap",
+			policy: publink.Policy{MaxTraffic: 100},
+			link:   link(day, func(l *pcloud.PublicLink) { l.Traffic = 100 }),
+			action: publink.ActionDelete,
+		},
+		{
+			name:    "uncapped traffic gets cap",
+			policy:  publink.Policy{MaxTraffic: 100},
+			link:    link(day, func(l *pcloud.PublicLink) { l.Traffic = 10 }),
+			action:  publink.ActionChange,
+			changes: &pcloud.PublicLinkOpts{MaxTraffic: 100},
+		},
+		{
+			name:   "stricter traffic cap kept",
+			policy: publink.Policy{MaxTraffic: 100},
+			link:   link(day, func(l *pcloud.PublicLink) { l.MaxTraffic = 50 }),
+			action: publink.ActionKeep,
+		},
+		{
+			name:   "downloads over cap",
+			policy: publink.Policy{MaxDownloads: 5},
+			link:   link(day, func(l *pcloud.PublicLink) { l.Downloads = 6 }),
+			action: publink.ActionDelete,
+		},
+		{
+			name:    "looser download cap lowered",
+			policy:  publink.Policy{MaxDownloads: 5},
+			link:    link(day, func(l *pcloud.PublicLink) { l.MaxDownloads = 10; l.Downloads = 1 }),
+			action:  publink.ActionChange,
+			changes: &pcloud.PublicLinkOpts{MaxDownloads: 5},
+		},
+		{
+			name:    "missing expiry set",
+			policy:  publink.Policy{RequireExpiry: 30 * day},
+			link:    link(day, nil),
+			action:  publink.ActionChange,
+			changes: &pcloud.PublicLinkOpts{ExpireTime: now.Add(30 * day).Unix()},
+		},
+		{
+			name:   "existing expiry kept",
+			policy: publink.Policy{RequireExpiry: 30 * day},
+			link:   link(day, func(l *pcloud.PublicLink) { l.Expires = pcloud.Time{Time: now.Add(day)} }),
+			action: publink.ActionKeep,
+		},
+		{
+			name:   "age wins over changes",
+			policy: publink.Policy{MaxAge: 90 * day, RequireExpiry: 30 * day},
+			link:   link(100*day, nil),
+			action: publink.ActionDelete,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.policy.Evaluate(tt.link, now)
+			if f.Action != tt.action {
+				t.Fatalf("Action = %s, want %s (reasons %q)", f.Action, tt.action, f.Reasons)
+			}
+			if tt.action != publink.ActionKeep && len(f.Reasons) == 0 {
+				t.Error("no reasons given")
+			}
+			if tt.changes != nil && (f.Changes == nil || *f.Changes != *tt.changes) {
+				t.Errorf("Changes = %+v, want %+v", f.Changes, tt.changes)
+			}
+		})
+	}
+}
+
+func TestEnforceCancelled(t *testing.T) {
+	var deleted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/listpublinks":
+			fmt.Fprint(w, `{"result":0,"publinks":[
+				{"linkid":1,"created":"Mon, 02 Jan 2006 15:04:05 +0000","metadata":{"fileid":1}},
+				{"linkid":2,"created":"Mon, 02 Jan 2006 15:04:05 +0000","metadata":{"fileid":2}}]}`)
+		case "/deletepublink":
+			_ = r.ParseForm()
+			deleted = append(deleted, r.Form.Get("linkid"))
+			fmt.Fprint(w, `{"result":0}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cancelAfterDelete := func(next pcloud.Handler) pcloud.Handler {
+		return func(ctx context.Context, call *pcloud.Call) (*pcloud.CallResponse, error) {
+			resp, err := next(ctx, call)
+			if call.Method == "deletepublink" {
+				cancel()
+			}
+			return resp, err
+		}
+	}
+	c := pcloud.NewClient(srv.URL, pcloud.WithRateLimit(60000), pcloud.WithMiddleware(cancelAfterDelete))
+
+	report, err := publink.Enforce(ctx, c, &publink.Policy{MaxAge: time.Hour}, false)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if report == nil {
+		t.Fatal("report is nil")
+	}
+	if !slices.Equal(deleted, []string{"1"}) {
+		t.Errorf("deleted links = %q, want [1]", deleted)
+	}
+	if report.Deleted != 1 || len(report.Findings) != 1 {
+		t.Errorf("deleted = %d, findings = %d, want 1 and 1", report.Deleted, len(report.Findings))
+	}
+}
@@ -0,0 +1,31 @@
+package pcloud
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPublicLinkKind(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want PublicLinkKind
+	}{
+		{"file", `{"metadata":{"isfolder":false,"fileid":5}}`, PublicLinkFile},
+		{"folder", `{"metadata":{"isfolder":true,"folderid":7}}`, PublicLinkFolder},
+		{"root folder", `{"metadata":{"isfolder":true,"folderid":0}}`, PublicLinkFolder},
+		{"tree", `{"metadata":{"isfolder":true,"name":"bundle","contents":[]}}`, PublicLinkTree},
+		{"error", `{"result":2009,"error":"File not found."}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var link PublicLink
+			if err := json.Unmarshal([]byte(tt.json), &link); err != nil {
+				t.Fatal(err)
+			}
+			if link.Kind != tt.want {
+				t.Errorf("Kind = %q, want %q", link.Kind, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package pcloud
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type PublicZipOpts struct {
+	Filename  string
+	FolderIDs []uint64
+	FileIDs   []uint64
+}
+
+type ThumbOpts struct {
+	Crop bool
+	Type string
+}
+
+func joinIDs(ids []uint64) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatUint(id, 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+func applyPublicZipOpts(params url.Values, opts *PublicZipOpts) {
+	if opts == nil {
+		return
+	}
+	if opts.Filename != "" {
+		params.Set("filename", opts.Filename)
+	}
+	if len(opts.FolderIDs) > 0 {
+		params.Set("folderids", joinIDs(opts.FolderIDs))
+	}
+	if len(opts.FileIDs) > 0 {
+		params.Set("fileids", joinIDs(opts.FileIDs))
+	}
+}
+
+func publicLinkParams(code string, fileID uint64) url.Values {
+	params := url.Values{
+		"code": {code},
+	}
+	if fileID > 0 {
+		params.Set("fileid", strconv.FormatUint(fileID, 10))
+	}
+	return params
+}
+
+func (c *Client) ListPublicLinkFolder(ctx context.Context, code string) (*Metadata, error) {
+	link, err := c.GetPublicLinkInfo(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return &link.Metadata, nil
+}
+
+func (c *Client) WalkPublicLink(ctx context.Context, code string) iter.Seq2[Metadata, error] {
+	return func(yield func(Metadata, error) bool) {
+		folder, err := c.ListPublicLinkFolder(ctx, code)
+		if err != nil {
+			yield(Metadata{}, err)
+			return
+		}
+		walkContents(folder.Contents, yield)
+	}
+}
+
+func (c *Client) GetPublicLinkDownload(ctx context.Context, code string, fileID uint64, opts *FileLinkOpts) (*FileLink, error) {
+	params := publicLinkParams(code, fileID)
+	applyLinkOpts(params, opts)
+
+	var resp FileLink
+	if err := c.do(ctx, "getpublinkdownload", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) DownloadPublicLink(ctx context.Context, code string, fileID uint64, opts *DownloadOpts) (io.ReadCloser, error) {
+	link, err := c.GetPublicLinkDownload(ctx, code, fileID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.downloadFromLink(ctx, link, opts)
+}
+
+func (c *Client) DownloadPublicLinkZip(ctx context.Context, code string, opts *PublicZipOpts) (io.ReadCloser, error) {
+	params := url.Values{
+		"code": {code},
+	}
+	applyPublicZipOpts(params, opts)
+	return c.doContent(ctx, "getpubzip", params)
+}
+
+func (c *Client) GetPublicLinkThumb(ctx context.Context, code string, fileID uint64, width, height int, opts *ThumbOpts) (io.ReadCloser, error) {
+	params := publicLinkParams(code, fileID)
+	params.Set("size", fmt.Sprintf("%dx%d", width, height))
+	if opts != nil {
+		if opts.Crop {
+			params.Set("crop", "1")
+		}
+		if opts.Type != "" {
+			params.Set("type", opts.Type)
+		}
+	}
+	return c.doContent(ctx, "getpubthumb", params)
+}
+
+func (c *Client) CopyPublicFile(ctx context.Context, code string, fileID, toFolderID uint64, toName string) (*Metadata, error) {
+	params := publicLinkParams(code, fileID)
+	params.Set("tofolderid", strconv.FormatUint(toFolderID, 10))
+	if toName != "" {
+		params.Set("toname", toName)
+	}
+
+	var resp fileResponse
+	if err := c.do(ctx, "copypubfile", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Metadata, nil
+}
+
+func (c *Client) SavePublicLinkZip(ctx context.Context, code string, toFolderID uint64, toName string, opts *PublicZipOpts) (*Metadata, error) {
+	params := url.Values{
+		"code":       {code},
+		"tofolderid": {strconv.FormatUint(toFolderID, 10)},
+	}
+	if toName != "" {
+		params.Set("toname", toName)
+	}
+	applyPublicZipOpts(params, opts)
+
+	var resp fileResponse
+	if err := c.do(ctx, "savepubzip", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Metadata, nil
+}
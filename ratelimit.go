@@ -0,0 +1,97 @@
+package pcloud
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	resultRateLimited = 4000
+
+	maxThrottleRetries   = 3
+	backoffFactor        = 0.5
+	recoveryFactor       = 1.25
+	recoveryThreshold    = 10
+	minRateFractionOfMax = 1.0 / 16
+)
+
+type Limiter struct {
+	mu        sync.Mutex
+	limiter   *rate.Limiter
+	maxRate   rate.Limit
+	successes int
+}
+
+func NewLimiter(rpm float64, burst int) *Limiter {
+	if rpm <= 0 {
+		rpm = DefaultRPM
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	limit := rate.Limit(rpm / 60.0)
+	return &Limiter{
+		limiter: rate.NewLimiter(limit, burst),
+		maxRate: limit,
+	}
+}
+
+func (l *Limiter) Wait(ctx context.Context) error {
+	return l.limiter.Wait(ctx)
+}
+
+func (l *Limiter) RPM() float64 {
+	return float64(l.limiter.Limit()) * 60.0
+}
+
+func (l *Limiter) MaxRPM() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return float64(l.maxRate) * 60.0
+}
+
+func (l *Limiter) Burst() int {
+	return l.limiter.Burst()
+}
+
+func (l *Limiter) Backoff() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.successes = 0
+	next := l.limiter.Limit() * backoffFactor
+	if floor := l.maxRate * minRateFractionOfMax; next < floor {
+		next = floor
+	}
+	l.limiter.SetLimit(next)
+}
+
+func (l *Limiter) Success() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	current := l.limiter.Limit()
+	if current >= l.maxRate {
+		return
+	}
+	l.successes++
+	if l.successes < recoveryThreshold {
+		return
+	}
+	l.successes = 0
+	l.limiter.SetLimit(min(current*recoveryFactor, l.maxRate))
+}
+
+func isThrottledStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+func (l *Limiter) observe(result int) {
+	switch result {
+	case 0:
+		l.Success()
+	case resultRateLimited:
+		l.Backoff()
+	}
+}
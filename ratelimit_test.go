@@ -0,0 +1,151 @@
+package pcloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLimiterBackoffAndRecovery(t *testing.T) {
+	l := NewLimiter(600, 1)
+	if l.RPM() != 600 || l.MaxRPM() != 600 {
+		t.Fatalf("RPM() = %v, MaxRPM() = %v", l.RPM(), l.MaxRPM())
+	}
+
+	l.Success()
+	if l.RPM() != 600 {
+		t.Errorf("Success() at max changed RPM to %v", l.RPM())
+	}
+
+	l.Backoff()
+	if l.RPM() != 300 {
+		t.Errorf("after Backoff RPM = %v, want 300", l.RPM())
+	}
+	for range 10 {
+		l.Backoff()
+	}
+	floor := 600 * minRateFractionOfMax
+	if l.RPM() != floor {
+		t.Errorf("after repeated Backoff RPM = %v, want floor %v", l.RPM(), floor)
+	}
+
+	for range recoveryThreshold - 1 {
+		l.Success()
+	}
+	if l.RPM() != floor {
+		t.Errorf("recovered before %d successes: RPM = %v", recoveryThreshold, l.RPM())
+	}
+	l.Success()
+	if want := floor * recoveryFactor; l.RPM() != want {
+		t.Errorf("after %d successes RPM = %v, want %v", recoveryThreshold, l.RPM(), want)
+	}
+
+	for range recoveryThreshold - 1 {
+		l.Success()
+	}
+	l.Backoff()
+	l.Success()
+	if want := floor * recoveryFactor * backoffFactor; l.RPM() != max(want, floor) {
+		t.Errorf("Backoff did not reset the success count: RPM = %v", l.RPM())
+	}
+
+	for range 100 * recoveryThreshold {
+		l.Success()
+	}
+	if l.RPM() != 600 {
+		t.Errorf("recovery exceeded or missed max: RPM = %v, want 600", l.RPM())
+	}
+}
+
+func TestLimiterObserve(t *testing.T) {
+	l := NewLimiter(600, 1)
+	l.observe(2000)
+	if l.RPM() != 600 {
+		t.Errorf("unrelated error changed RPM to %v", l.RPM())
+	}
+	l.observe(resultRateLimited)
+	if l.RPM() != 300 {
+		t.Errorf("result %d: RPM = %v, want 300", resultRateLimited, l.RPM())
+	}
+}
+
+func TestThrottledRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"result":0}`)
+	}))
+	defer srv.Close()
+
+	var retries int
+	capture := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (*CallResponse, error) {
+			resp, err := next(ctx, call)
+			if resp != nil {
+				retries = resp.Retries
+			}
+			return resp, err
+		}
+	}
+	l := NewLimiter(60000, 1)
+	c := NewClient(srv.URL, WithLimiter(l), WithMiddleware(capture))
+	if err := c.Call(context.Background(), "userinfo", nil, &Error{}); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 3 || retries != 2 {
+		t.Errorf("calls = %d, retries = %d, want 3 and 2", calls.Load(), retries)
+	}
+	if want := 60000 * backoffFactor * backoffFactor; l.RPM() != want {
+		t.Errorf("RPM = %v, want %v", l.RPM(), want)
+	}
+}
+
+func TestThrottledRetriesGiveUp(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, WithLimiter(NewLimiter(60000, 1)))
+	if err := c.Call(context.Background(), "userinfo", nil, &Error{}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls.Load() != maxThrottleRetries+1 {
+		t.Errorf("calls = %d, want %d", calls.Load(), maxThrottleRetries+1)
+	}
+
+	calls.Store(0)
+	body := strings.NewReader("a=1")
+	if err := c.CallPost(context.Background(), "uploadfile", nil, body, "application/x-www-form-urlencoded", &Error{}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("request with body sent %d times, want 1", calls.Load())
+	}
+}
+
+func TestRateLimitedResultSlowsSharedLimiter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"result":%d,"error":"Too many requests."}`, resultRateLimited)
+	}))
+	defer srv.Close()
+
+	l := NewLimiter(6000, 1)
+	a := NewClient(srv.URL, WithLimiter(l))
+	b := a.With(WithAuthToken("other"))
+	if err := a.Call(context.Background(), "userinfo", nil, &Error{}); err == nil {
+		t.Fatal("expected an API error")
+	}
+	if b.Limiter() != l || l.RPM() != 3000 {
+		t.Errorf("shared limiter RPM = %v, want 3000", b.Limiter().RPM())
+	}
+}
@@ -0,0 +1,44 @@
+package pcloud
+
+import (
+	"context"
+	"io"
+	"maps"
+	"net/url"
+)
+
+// Call invokes an API method this package does not wrap. The request goes
+// through the same authentication, rate limiting, retries, logging and
+// middleware as every other call, and result must report the API result
+// code, typically by embedding Error.
+func (c *Client) Call(ctx context.Context, method string, params url.Values, result APIResult) error {
+	return c.do(ctx, method, cloneParams(params), result)
+}
+
+// CallPost is like Call but sends body, for example a multipart form, as the
+// request body with the given content type. Credentials travel inside the
+// body, so an authenticated client only accepts multipart and URL-encoded
+// form bodies.
+func (c *Client) CallPost(ctx context.Context, method string, params url.Values, body io.Reader, contentType string, result APIResult) error {
+	return c.doPost(ctx, method, cloneParams(params), body, contentType, result)
+}
+
+// CallJSON invokes method with Call and decodes the response into a new T.
+// T must embed Error (or otherwise implement APIResult on *T).
+func CallJSON[T any, PT interface {
+	*T
+	APIResult
+}](ctx context.Context, c *Client, method string, params url.Values) (*T, error) {
+	result := PT(new(T))
+	if err := c.Call(ctx, method, params, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func cloneParams(params url.Values) url.Values {
+	if params == nil {
+		return url.Values{}
+	}
+	return maps.Clone(params)
+}
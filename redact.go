@@ -0,0 +1,186 @@
+package pcloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const redacted = "REDACTED"
+
+// sensitiveNames lists the API parameters and response fields that carry
+// credentials.
+var sensitiveNames = []string{
+	"auth",
+	"password",
+	"passworddigest",
+	"access_token",
+	"refresh_token",
+	"client_secret",
+	"linkpassword",
+	"token",
+}
+
+var sensitiveParams = func() map[string]bool {
+	m := make(map[string]bool, len(sensitiveNames))
+	for _, name := range sensitiveNames {
+		m[name] = true
+	}
+	return m
+}()
+
+var sensitivePattern = regexp.MustCompile(`\b(` + strings.Join(sensitiveNames, "|") + `)=[^&\s"']*`)
+
+// IsSensitiveParam reports whether an API parameter or response field named
+// name carries a credential. The client never puts such values in URLs and
+// scrubs them from errors and logs; tools that store traffic, such as
+// pcloudtest/recorder, should scrub them too.
+func IsSensitiveParam(name string) bool {
+	return sensitiveParams[name]
+}
+
+func redactString(s string) string {
+	return sensitivePattern.ReplaceAllString(s, "${1}="+redacted)
+}
+
+func redactParams(params url.Values) url.Values {
+	out := make(url.Values, len(params))
+	for key, values := range params {
+		if sensitiveParams[key] {
+			out[key] = []string{redacted}
+			continue
+		}
+		out[key] = values
+	}
+	return out
+}
+
+func splitSensitive(params url.Values) (public, sensitive url.Values) {
+	public = make(url.Values, len(params))
+	sensitive = url.Values{}
+	for key, values := range params {
+		if sensitiveParams[key] {
+			sensitive[key] = values
+			continue
+		}
+		public[key] = values
+	}
+	return public, sensitive
+}
+
+// bodyPrefix renders fields so they can be sent ahead of an already encoded
+// multipart or URL-encoded form body. Other bodies have no place for them.
+func bodyPrefix(contentType string, fields url.Values) (string, bool) {
+	mediaType, mediaParams, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	if mediaType == "application/x-www-form-urlencoded" {
+		return fields.Encode() + "&", true
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") || mediaParams["boundary"] == "" {
+		return "", false
+	}
+
+	var sb strings.Builder
+	for key, values := range fields {
+		for _, value := range values {
+			fmt.Fprintf(&sb, "--%s\r\nContent-Disposition: form-data; name=%q\r\n\r\n%s\r\n", mediaParams["boundary"], key, value)
+		}
+	}
+	return sb.String(), true
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+func redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		clean := *urlErr
+		clean.URL = redactString(urlErr.URL)
+		return &clean
+	}
+	msg := err.Error()
+	if scrubbed := redactString(msg); scrubbed != msg {
+		return &redactedError{msg: scrubbed, err: err}
+	}
+	return err
+}
+
+type redactHandler struct {
+	next slog.Handler
+}
+
+func newRedactLogger(logger *slog.Logger) *slog.Logger {
+	if _, ok := logger.Handler().(*redactHandler); ok {
+		return logger
+	}
+	return slog.New(&redactHandler{next: logger.Handler()})
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	clean := slog.NewRecord(r.Time, r.Level, redactString(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		clean.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, clean)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clean := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		clean[i] = redactAttr(a)
+	}
+	return &redactHandler{next: h.next.WithAttrs(clean)}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name)}
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	if sensitiveParams[a.Key] {
+		return slog.String(a.Key, redacted)
+	}
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, redactString(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		clean := make([]any, len(group))
+		for i, ga := range group {
+			clean[i] = redactAttr(ga)
+		}
+		return slog.Group(a.Key, clean...)
+	case slog.KindAny:
+		if err, ok := v.Any().(error); ok {
+			return slog.String(a.Key, redactString(err.Error()))
+		}
+		if params, ok := v.Any().(url.Values); ok {
+			return slog.String(a.Key, redactParams(params).Encode())
+		}
+		return slog.Attr{Key: a.Key, Value: v}
+	default:
+		return slog.Attr{Key: a.Key, Value: v}
+	}
+}
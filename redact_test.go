@@ -0,0 +1,238 @@
+package pcloud
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testSecret = "s3cr3t-value"
+
+type capturedRequest struct {
+	url  string
+	form url.Values
+}
+
+func captureServer(t *testing.T) (*httptest.Server, func() []capturedRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var reqs []capturedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			t.Errorf("parse form: %v", err)
+		}
+		mu.Lock()
+		reqs = append(reqs, capturedRequest{url: r.URL.String(), form: r.PostForm})
+		mu.Unlock()
+		fmt.Fprint(w, `{"result":0,"digest":"d","auth":"t"}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []capturedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return reqs
+	}
+}
+
+func TestCredentialsStayOutOfURL(t *testing.T) {
+	multipartBody := func() (io.Reader, string) {
+		var body bytes.Buffer
+		w := multipart.NewWriter(&body)
+		part, _ := w.CreateFormFile("file", "a.txt")
+		_, _ = part.Write([]byte("hello"))
+		_ = w.Close()
+		return &body, w.FormDataContentType()
+	}
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context, c *Client) error
+		want url.Values
+	}{
+		{
+			name: "get",
+			call: func(ctx context.Context, c *Client) error {
+				return c.Call(ctx, "userinfo", nil, &Error{})
+			},
+			want: url.Values{"auth": {testSecret}},
+		},
+		{
+			name: "plain password login",
+			call: func(ctx context.Context, c *Client) error {
+				return c.LoginWithOpts(ctx, "user", testSecret, &LoginOpts{PlainPassword: true})
+			},
+			want: url.Values{"auth": {testSecret}, "password": {testSecret}},
+		},
+		{
+			name: "multipart body",
+			call: func(ctx context.Context, c *Client) error {
+				body, contentType := multipartBody()
+				return c.CallPost(ctx, "uploadfile", url.Values{"folderid": {"0"}}, body, contentType, &Error{})
+			},
+			want: url.Values{"auth": {testSecret}},
+		},
+		{
+			name: "form body",
+			call: func(ctx context.Context, c *Client) error {
+				body := strings.NewReader(url.Values{"linkpassword": {testSecret}}.Encode())
+				return c.CallPost(ctx, "changepublink", url.Values{"linkid": {"1"}}, body, "application/x-www-form-urlencoded", &Error{})
+			},
+			want: url.Values{"auth": {testSecret}, "linkpassword": {testSecret}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := captureServer(t)
+			c := NewClient(srv.URL, WithAuthToken(testSecret), WithRateLimit(60000))
+			if err := tt.call(context.Background(), c); err != nil {
+				t.Fatal(err)
+			}
+			reqs := requests()
+			if len(reqs) == 0 {
+				t.Fatal("no request sent")
+			}
+			last := reqs[len(reqs)-1]
+			for _, req := range reqs {
+				if strings.Contains(req.url, testSecret) {
+					t.Errorf("secret in URL %s", req.url)
+				}
+			}
+			for key, values := range tt.want {
+				if got := last.form[key]; len(got) != 1 || got[0] != values[0] {
+					t.Errorf("form %s = %q, want %q", key, got, values)
+				}
+			}
+		})
+	}
+}
+
+func TestCredentialsRejectedInOpaqueBody(t *testing.T) {
+	srv, requests := captureServer(t)
+	c := NewClient(srv.URL, WithAuthToken(testSecret), WithRateLimit(60000))
+
+	err := c.CallPost(context.Background(), "custom", nil, strings.NewReader(`{"a":1}`), "application/json", &Error{})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if strings.Contains(err.Error(), testSecret) {
+		t.Errorf("secret in error %q", err)
+	}
+	if n := len(requests()); n != 0 {
+		t.Errorf("%d requests sent, want none", n)
+	}
+}
+
+func TestTransportErrorRedacted(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := NewClient(srv.URL, WithAuthToken(testSecret), WithLogger(logger), WithRateLimit(60000))
+
+	err := c.Call(context.Background(), "userinfo", url.Values{"password": {testSecret}}, &Error{})
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		t.Fatalf("err = %v, want *url.Error", err)
+	}
+	if strings.Contains(err.Error(), testSecret) {
+		t.Errorf("secret in error %q", err)
+	}
+	if strings.Contains(logs.String(), testSecret) {
+		t.Errorf("secret in logs:\n%s", logs.String())
+	}
+}
+
+func TestRedactError(t *testing.T) {
+	base := errors.New("boom")
+	urlErr := redactError(&url.Error{Op: "Get", URL: "https://api.pcloud.com/userinfo?auth=" + testSecret + "&x=1", Err: base})
+	if strings.Contains(urlErr.Error(), testSecret) {
+		t.Errorf("secret in %q", urlErr)
+	}
+	if !errors.Is(urlErr, base) {
+		t.Error("url error no longer wraps the cause")
+	}
+
+	plain := redactError(fmt.Errorf("call failed: password=%s", testSecret))
+	if strings.Contains(plain.Error(), testSecret) {
+		t.Errorf("secret in %q", plain)
+	}
+
+	if err := redactError(base); err != base {
+		t.Errorf("clean error replaced: %v", err)
+	}
+	if redactError(nil) != nil {
+		t.Error("redactError(nil) != nil")
+	}
+}
+
+func TestRedactHandler(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newRedactLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	logger.With("auth", testSecret).WithGroup("req").Info("calling ?access_token="+testSecret,
+		"password", testSecret,
+		"url", "https://api.pcloud.com/x?client_secret="+testSecret,
+		"params", url.Values{"linkpassword": {testSecret}, "folderid": {"1"}},
+		"error", fmt.Errorf("failed: passworddigest=%s", testSecret),
+		slog.Group("nested", "auth", testSecret, "note", "auth="+testSecret),
+	)
+
+	out := buf.String()
+	if strings.Contains(out, testSecret) {
+		t.Errorf("secret in log output:\n%s", out)
+	}
+	if !strings.Contains(out, "folderid=1") {
+		t.Errorf("non-sensitive params lost:\n%s", out)
+	}
+	if newRedactLogger(logger) != logger {
+		t.Error("redact logger wrapped twice")
+	}
+}
+
+func TestSplitSensitive(t *testing.T) {
+	params := url.Values{
+		"auth":     {"a"},
+		"password": {"p"},
+		"folderid": {"1"},
+		"name":     {"x"},
+	}
+	public, sensitive := splitSensitive(params)
+	if public.Encode() != "folderid=1&name=x" {
+		t.Errorf("public = %s", public.Encode())
+	}
+	if sensitive.Encode() != "auth=a&password=p" {
+		t.Errorf("sensitive = %s", sensitive.Encode())
+	}
+}
+
+func TestBodyPrefix(t *testing.T) {
+	fields := url.Values{"auth": {testSecret}}
+
+	prefix, ok := bodyPrefix("multipart/form-data; boundary=xyz", fields)
+	want := "--xyz\r\nContent-Disposition: form-data; name=\"auth\"\r\n\r\n" + testSecret + "\r\n"
+	if !ok || prefix != want {
+		t.Errorf("multipart prefix = %q, %v", prefix, ok)
+	}
+
+	prefix, ok = bodyPrefix("application/x-www-form-urlencoded", fields)
+	if !ok || prefix != "auth="+testSecret+"&" {
+		t.Errorf("form prefix = %q, %v", prefix, ok)
+	}
+
+	for _, contentType := range []string{"application/json", "multipart/form-data", "", "text/plain; charset"} {
+		if _, ok := bodyPrefix(contentType, fields); ok {
+			t.Errorf("bodyPrefix(%q) accepted", contentType)
+		}
+	}
+}
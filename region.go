@@ -0,0 +1,147 @@
+package pcloud
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	resultLoginRequired      = 1000
+	resultLoginFailed        = 2000
+	resultInvalidAccessToken = 2094
+)
+
+type APIServers struct {
+	Error
+	API    []string `json:"api"`
+	BinAPI []string `json:"binapi"`
+}
+
+func (c *Client) BaseURL() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.baseURL
+}
+
+func (c *Client) GetAPIServer(ctx context.Context) (*APIServers, error) {
+	var resp APIServers
+	if err := c.do(ctx, "getapiserver", url.Values{}, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) UseNearestAPIServer(ctx context.Context) error {
+	servers, err := c.GetAPIServer(ctx)
+	if err != nil {
+		return err
+	}
+	if len(servers.API) == 0 {
+		return errors.New("getapiserver: no api servers in response")
+	}
+	c.pinRegion("https://" + servers.API[0])
+	if c.AuthToken() != "" {
+		return c.saveCredentials(ctx)
+	}
+	return nil
+}
+
+func (c *Client) pinRegion(baseURL string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.baseURL = baseURL
+	c.autoRegion = false
+}
+
+func regionCandidates(current string) []string {
+	if current == BaseURLEU {
+		return []string{BaseURLEU, BaseURLUS}
+	}
+	return []string{BaseURLUS, BaseURLEU}
+}
+
+func isWrongRegion(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.Result {
+	case resultLoginRequired, resultLoginFailed, resultInvalidAccessToken:
+		return true
+	}
+	return false
+}
+
+// resolveRegion pins the client to the region that accepts its OAuth or
+// auth token. It is a no-op unless the client was created without an
+// explicit base URL, and until it has a token.
+func (c *Client) resolveRegion(ctx context.Context) error {
+	c.mu.RLock()
+	autoRegion, ts, auth := c.autoRegion, c.tokenSource, c.auth
+	c.mu.RUnlock()
+	if !autoRegion || (ts == nil && auth == "") {
+		return nil
+	}
+
+	c.regionMu.Lock()
+	defer c.regionMu.Unlock()
+
+	c.mu.RLock()
+	autoRegion, current := c.autoRegion, c.baseURL
+	c.mu.RUnlock()
+	if !autoRegion {
+		return nil
+	}
+
+	if ts != nil {
+		token, err := ts.Token()
+		if err != nil {
+			return err
+		}
+		if baseURL := tokenRegion(token); baseURL != "" {
+			c.pinRegion(baseURL)
+			return nil
+		}
+	}
+
+	var lastErr error
+	for _, baseURL := range regionCandidates(current) {
+		var resp UserInfo
+		err := c.withBaseURL(baseURL).do(ctx, "userinfo", url.Values{}, &resp)
+		if err == nil {
+			c.pinRegion(baseURL)
+			if ts == nil {
+				return c.saveCredentials(ctx)
+			}
+			return nil
+		}
+		if !isWrongRegion(err) {
+			return err
+		}
+		lastErr = err
+	}
+	return lastErr
+}
+
+// tokenRegion returns the base URL recorded in an OAuth token by
+// ExchangeCode, or "" if the token does not say. A hostname that is not a
+// pCloud API host is ignored in favour of the location ID.
+func tokenRegion(token *oauth2.Token) string {
+	if hostname, ok := token.Extra("hostname").(string); ok && isAPIHost(hostname) {
+		return "https://" + hostname
+	}
+	switch id := token.Extra("locationid").(type) {
+	case int:
+		if id != 0 {
+			return BaseURLForLocation(id)
+		}
+	case float64:
+		if id != 0 {
+			return BaseURLForLocation(int(id))
+		}
+	}
+	return ""
+}
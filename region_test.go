@@ -0,0 +1,102 @@
+package pcloud
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func jsonResponse(req *http.Request, body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func TestResolveRegionAuthToken(t *testing.T) {
+	var mu sync.Mutex
+	var hosts []string
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		mu.Lock()
+		hosts = append(hosts, req.URL.Host)
+		mu.Unlock()
+		if req.URL.Host == "eapi.pcloud.com" {
+			return jsonResponse(req, `{"result":0,"userid":7,"email":"eu@example.com"}`), nil
+		}
+		return jsonResponse(req, `{"result":1000,"error":"Log in required."}`), nil
+	})
+
+	c := NewClient("",
+		WithAuthToken("eu-token"),
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithRateLimit(60000),
+	)
+	info, err := c.UserInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Email != "eu@example.com" {
+		t.Errorf("email = %q", info.Email)
+	}
+	if got := c.BaseURL(); got != BaseURLEU {
+		t.Errorf("BaseURL() = %q, want %q", got, BaseURLEU)
+	}
+	want := []string{"api.pcloud.com", "eapi.pcloud.com", "eapi.pcloud.com"}
+	if !slices.Equal(hosts, want) {
+		t.Errorf("hosts = %q, want %q", hosts, want)
+	}
+}
+
+func TestResolveRegionWithoutToken(t *testing.T) {
+	var hosts []string
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		hosts = append(hosts, req.URL.Host)
+		return jsonResponse(req, `{"result":0,"digest":"abc"}`), nil
+	})
+
+	c := NewClient("", WithHTTPClient(&http.Client{Transport: transport}), WithRateLimit(60000))
+	var resp digestResponse
+	if err := c.do(context.Background(), "getdigest", nil, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(hosts, []string{"api.pcloud.com"}) {
+		t.Errorf("hosts = %q, want only the default host", hosts)
+	}
+}
+
+func TestTokenRegion(t *testing.T) {
+	tests := []struct {
+		name  string
+		extra map[string]any
+		want  string
+	}{
+		{"hostname", map[string]any{"hostname": "eapi.pcloud.com"}, BaseURLEU},
+		{"location int", map[string]any{"locationid": LocationEU}, BaseURLEU},
+		{"location float", map[string]any{"locationid": float64(LocationUS)}, BaseURLUS},
+		{"unknown host falls back", map[string]any{"hostname": "evil.example.com", "locationid": LocationEU}, BaseURLEU},
+		{"unknown host alone", map[string]any{"hostname": "evil.example.com"}, ""},
+		{"nothing", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := (&oauth2.Token{AccessToken: "x"}).WithExtra(tt.extra)
+			if got := tokenRegion(token); got != tt.want {
+				t.Errorf("tokenRegion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
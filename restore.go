@@ -0,0 +1,288 @@
+package pcloud
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultRestoreConcurrency = 4
+
+type RestoreAction string
+
+const (
+	RestoreRevert       RestoreAction = "revert"
+	RestoreCopyRevision RestoreAction = "copy-revision"
+	RestoreCopyCurrent  RestoreAction = "copy-current"
+	RestoreUnchanged    RestoreAction = "unchanged"
+	RestoreSkip         RestoreAction = "skip"
+)
+
+type RestoreOpts struct {
+	DryRun         bool
+	TargetFolderID uint64
+	Concurrency    int
+}
+
+type RestoreItem struct {
+	File     Metadata
+	Dir      string
+	Revision *Revision
+	Action   RestoreAction
+	Result   *Metadata
+	Err      error
+}
+
+type RestoreReport struct {
+	Time      time.Time
+	DryRun    bool
+	Items     []RestoreItem
+	Reverted  int
+	Copied    int
+	Unchanged int
+	Skipped   int
+	Failed    int
+}
+
+func (r *RestoreReport) String() string {
+	var sb strings.Builder
+	for _, item := range r.Items {
+		name := path.Join("/", item.Dir, item.File.Name)
+		switch {
+		case item.Err != nil:
+			fmt.Fprintf(&sb, "%-13s %s: %v\n", item.Action, name, item.Err)
+		case item.Revision != nil:
+			fmt.Fprintf(&sb, "%-13s %s (revision %d, %s)\n", item.Action, name, item.Revision.RevisionID, item.Revision.Created.Format(time.RFC3339))
+		default:
+			fmt.Fprintf(&sb, "%-13s %s\n", item.Action, name)
+		}
+	}
+	fmt.Fprintf(&sb, "reverted: %d, copied: %d, unchanged: %d, skipped: %d, failed: %d\n",
+		r.Reverted, r.Copied, r.Unchanged, r.Skipped, r.Failed)
+	return sb.String()
+}
+
+// RestoreFolderToTime brings every file under folderID back to its state at
+// t. If ctx is cancelled part way, the returned report records what was
+// already changed, along with ctx.Err().
+func (c *Client) RestoreFolderToTime(ctx context.Context, folderID uint64, t time.Time, opts *RestoreOpts) (*RestoreReport, error) {
+	if opts == nil {
+		opts = &RestoreOpts{}
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultRestoreConcurrency
+	}
+
+	folder, err := c.ListFolder(ctx, folderID, &ListFolderOpts{Recursive: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var items []RestoreItem
+	collectRestoreItems(folder.Contents, "", &items)
+
+	report := &RestoreReport{Time: t, DryRun: opts.DryRun, Items: items}
+
+	started := forEachConcurrent(ctx, items, concurrency, func(item *RestoreItem) {
+		c.planRestore(ctx, item, t, opts.TargetFolderID != 0)
+	})
+	if err := ctx.Err(); err != nil {
+		for i := range items {
+			item := &items[i]
+			switch {
+			case i >= started:
+				item.Action = RestoreSkip
+				item.Err = err
+			case !opts.DryRun && item.Err == nil && item.Action != RestoreUnchanged && item.Action != RestoreSkip:
+				// Planned but never applied.
+				item.Err = err
+			}
+		}
+		report.count()
+		return report, err
+	}
+
+	if !opts.DryRun {
+		folders := &restoreFolders{client: c, root: opts.TargetFolderID, ids: map[string]uint64{}}
+		started := forEachConcurrent(ctx, items, concurrency, func(item *RestoreItem) {
+			c.applyRestore(ctx, item, folders)
+		})
+		if err := ctx.Err(); err != nil {
+			for i := started; i < len(items); i++ {
+				if items[i].Err == nil && items[i].Action != RestoreUnchanged && items[i].Action != RestoreSkip {
+					items[i].Err = err
+				}
+			}
+			report.count()
+			return report, err
+		}
+	}
+
+	report.count()
+	return report, nil
+}
+
+func (r *RestoreReport) count() {
+	for _, item := range r.Items {
+		switch {
+		case item.Err != nil:
+			r.Failed++
+		case item.Action == RestoreRevert:
+			r.Reverted++
+		case item.Action == RestoreCopyRevision, item.Action == RestoreCopyCurrent:
+			r.Copied++
+		case item.Action == RestoreUnchanged:
+			r.Unchanged++
+		default:
+			r.Skipped++
+		}
+	}
+}
+
+func collectRestoreItems(contents []Metadata, dir string, items *[]RestoreItem) {
+	for _, item := range contents {
+		if item.IsFolder {
+			collectRestoreItems(item.Contents, path.Join(dir, item.Name), items)
+			continue
+		}
+		*items = append(*items, RestoreItem{File: item, Dir: dir})
+	}
+}
+
+// forEachConcurrent runs fn on items in order until ctx is done and returns
+// how many items it started.
+func forEachConcurrent(ctx context.Context, items []RestoreItem, concurrency int, fn func(*RestoreItem)) int {
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	started := 0
+	for i := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		started++
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(item *RestoreItem) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(item)
+		}(&items[i])
+	}
+	wg.Wait()
+	return started
+}
+
+func (c *Client) planRestore(ctx context.Context, item *RestoreItem, t time.Time, toFolder bool) {
+	if !item.File.Modified.After(t) {
+		item.Action = RestoreUnchanged
+		if toFolder {
+			item.Action = RestoreCopyCurrent
+		}
+		return
+	}
+
+	revisions, err := c.ListRevisions(ctx, item.File.FileID)
+	if err != nil {
+		item.Action = RestoreSkip
+		item.Err = err
+		return
+	}
+
+	var best *Revision
+	for i := range revisions {
+		rev := &revisions[i]
+		if rev.Created.After(t) {
+			continue
+		}
+		if best == nil || rev.Created.After(best.Created.Time) {
+			best = rev
+		}
+	}
+	if best == nil {
+		item.Action = RestoreSkip
+		return
+	}
+
+	item.Revision = best
+	item.Action = RestoreRevert
+	if toFolder {
+		item.Action = RestoreCopyRevision
+	} else if !best.Hash.IsZero() && best.Hash == item.File.Hash {
+		item.Action = RestoreUnchanged
+	}
+}
+
+func (c *Client) applyRestore(ctx context.Context, item *RestoreItem, folders *restoreFolders) {
+	if item.Err != nil {
+		return
+	}
+
+	var err error
+	switch item.Action {
+	case RestoreRevert:
+		item.Result, err = c.RevertRevision(ctx, item.File.FileID, item.Revision.RevisionID)
+	case RestoreCopyCurrent:
+		var dirID uint64
+		if dirID, err = folders.get(ctx, item.Dir); err == nil {
+			item.Result, err = c.CopyFile(ctx, item.File.FileID, dirID)
+		}
+	case RestoreCopyRevision:
+		item.Result, err = c.copyRevision(ctx, item, folders)
+	case RestoreUnchanged, RestoreSkip:
+	}
+	item.Err = err
+}
+
+func (c *Client) copyRevision(ctx context.Context, item *RestoreItem, folders *restoreFolders) (*Metadata, error) {
+	dirID, err := folders.get(ctx, item.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.DownloadRevision(ctx, item.File.FileID, item.Revision.RevisionID, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return c.Upload(ctx, dirID, item.File.Name, body, &UploadOpts{
+		ModifiedTime: item.Revision.Created.Unix(),
+	})
+}
+
+type restoreFolders struct {
+	client *Client
+	root   uint64
+	mu     sync.Mutex
+	ids    map[string]uint64
+}
+
+func (f *restoreFolders) get(ctx context.Context, dir string) (uint64, error) {
+	if dir == "" {
+		return f.root, nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	parentID := f.root
+	current := ""
+	for _, name := range strings.Split(dir, "/") {
+		current = path.Join(current, name)
+		if id, ok := f.ids[current]; ok {
+			parentID = id
+			continue
+		}
+		folder, err := f.client.CreateFolderIfNotExists(ctx, parentID, name)
+		if err != nil {
+			return 0, err
+		}
+		f.ids[current] = folder.FolderID
+		parentID = folder.FolderID
+	}
+	return parentID, nil
+}
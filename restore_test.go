@@ -0,0 +1,118 @@
+package pcloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRestoreFolderToTimeCancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/listfolder":
+			fmt.Fprint(w, `{"result":0,"metadata":{"isfolder":true,"contents":[
+				{"name":"a.txt","fileid":1,"modified":"Mon, 02 Jan 2006 15:04:05 +0000"},
+				{"name":"b.txt","fileid":2,"modified":"Mon, 02 Jan 2006 15:04:05 +0000"}]}}`)
+		case "/listrevisions":
+			fmt.Fprint(w, `{"result":0,"revisions":[{"revisionid":10,"created":"Sun, 01 Jan 2006 00:00:00 +0000"}]}`)
+		case "/revertrevision":
+			fmt.Fprint(w, `{"result":0,"metadata":{"fileid":1}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cancelAfterRevert := func(next Handler) Handler {
+		return func(ctx context.Context, call *Call) (*CallResponse, error) {
+			resp, err := next(ctx, call)
+			if call.Method == "revertrevision" {
+				cancel()
+			}
+			return resp, err
+		}
+	}
+	c := NewClient(srv.URL, WithRateLimit(60000), WithMiddleware(cancelAfterRevert))
+
+	at := time.Date(2006, 1, 1, 12, 0, 0, 0, time.UTC)
+	report, err := c.RestoreFolderToTime(ctx, 0, at, &RestoreOpts{Concurrency: 1})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if report == nil {
+		t.Fatal("report is nil")
+	}
+	if report.Reverted != 1 || report.Failed != 1 {
+		t.Errorf("reverted = %d, failed = %d, want 1 and 1", report.Reverted, report.Failed)
+	}
+	if item := report.Items[0]; item.Result == nil || item.Err != nil {
+		t.Errorf("first item = %+v, want reverted", item)
+	}
+	if item := report.Items[1]; !errors.Is(item.Err, context.Canceled) {
+		t.Errorf("second item err = %v, want context.Canceled", item.Err)
+	}
+}
+
+func TestRestoreFolderToTimeCancelledWhilePlanning(t *testing.T) {
+	var reverted int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/listfolder":
+			fmt.Fprint(w, `{"result":0,"metadata":{"isfolder":true,"contents":[
+				{"name":"a.txt","fileid":1,"modified":"Mon, 02 Jan 2006 15:04:05 +0000"},
+				{"name":"b.txt","fileid":2,"modified":"Mon, 02 Jan 2006 15:04:05 +0000"}]}}`)
+		case "/listrevisions":
+			fmt.Fprint(w, `{"result":0,"revisions":[{"revisionid":10,"created":"Sun, 01 Jan 2006 00:00:00 +0000"}]}`)
+		case "/revertrevision":
+			reverted++
+			fmt.Fprint(w, `{"result":0,"metadata":{"fileid":1}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	for _, dryRun := range []bool{false, true} {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancelAfterPlan := func(next Handler) Handler {
+			return func(ctx context.Context, call *Call) (*CallResponse, error) {
+				resp, err := next(ctx, call)
+				if call.Method == "listrevisions" {
+					cancel()
+				}
+				return resp, err
+			}
+		}
+		c := NewClient(srv.URL, WithRateLimit(60000), WithMiddleware(cancelAfterPlan))
+
+		at := time.Date(2006, 1, 1, 12, 0, 0, 0, time.UTC)
+		report, err := c.RestoreFolderToTime(ctx, 0, at, &RestoreOpts{Concurrency: 1, DryRun: dryRun})
+		cancel()
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("dry run %v: err = %v, want context.Canceled", dryRun, err)
+		}
+		if reverted != 0 {
+			t.Fatalf("dry run %v: revertrevision called %d times", dryRun, reverted)
+		}
+		if dryRun {
+			if report.Reverted != 1 || report.Failed != 1 {
+				t.Errorf("dry run: reverted = %d, failed = %d, want 1 and 1", report.Reverted, report.Failed)
+			}
+			continue
+		}
+		if report.Reverted != 0 || report.Failed != 2 {
+			t.Errorf("reverted = %d, failed = %d, want 0 and 2", report.Reverted, report.Failed)
+		}
+		for _, item := range report.Items {
+			if !errors.Is(item.Err, context.Canceled) {
+				t.Errorf("%s: err = %v, want context.Canceled", item.File.Name, item.Err)
+			}
+		}
+	}
+}
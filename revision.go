@@ -1,9 +1,13 @@
 package pcloud
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"net/url"
 	"strconv"
+	"unicode/utf8"
 )
 
 type revisionsResponse struct {
@@ -65,3 +69,49 @@ func (c *Client) RevertRevisionByPath(ctx context.Context, path string, revision
 	}
 	return &resp.Metadata, nil
 }
+
+func (c *Client) DownloadRevision(ctx context.Context, fileID, revisionID uint64, opts *DownloadOpts) (io.ReadCloser, error) {
+	params := url.Values{
+		"fileid":     {strconv.FormatUint(fileID, 10)},
+		"revisionid": {strconv.FormatUint(revisionID, 10)},
+	}
+
+	var link FileLink
+	if err := c.do(ctx, "getfilelink", params, &link); err != nil {
+		return nil, err
+	}
+	return c.downloadFromLink(ctx, &link, opts)
+}
+
+func (c *Client) RevisionDiff(ctx context.Context, fileID, oldRevisionID, newRevisionID uint64) (string, error) {
+	oldContent, err := c.readRevision(ctx, fileID, oldRevisionID)
+	if err != nil {
+		return "", err
+	}
+	newContent, err := c.readRevision(ctx, fileID, newRevisionID)
+	if err != nil {
+		return "", err
+	}
+
+	return unifiedDiff(
+		fmt.Sprintf("revision %d", oldRevisionID), splitLines(oldContent),
+		fmt.Sprintf("revision %d", newRevisionID), splitLines(newContent),
+	), nil
+}
+
+func (c *Client) readRevision(ctx context.Context, fileID, revisionID uint64) (string, error) {
+	body, err := c.DownloadRevision(ctx, fileID, revisionID, nil)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	if bytes.IndexByte(content, 0) >= 0 || !utf8.Valid(content) {
+		return "", fmt.Errorf("revision %d is not a text file", revisionID)
+	}
+	return string(content), nil
+}
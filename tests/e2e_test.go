@@ -48,6 +48,24 @@ func TestAuth(t *testing.T) {
 		}
 	})
 
+	t.Run("LoginPlainPassword", func(t *testing.T) {
+		plain := pcloud.NewClient(baseURL)
+		if err := plain.LoginWithOpts(ctx, username, password, &pcloud.LoginOpts{PlainPassword: true}); err != nil {
+			t.Fatalf("plain login failed: %v", err)
+		}
+		plain.Logout(ctx)
+	})
+
+	t.Run("GetAPIServer", func(t *testing.T) {
+		servers, err := c.GetAPIServer(ctx)
+		if err != nil {
+			t.Fatalf("getapiserver failed: %v", err)
+		}
+		if len(servers.API) == 0 {
+			t.Fatal("no api servers")
+		}
+	})
+
 	t.Run("UserInfo", func(t *testing.T) {
 		info, err := c.UserInfo(ctx)
 		if err != nil {
@@ -61,6 +79,63 @@ func TestAuth(t *testing.T) {
 		}
 	})
 
+	t.Run("ListTokens", func(t *testing.T) {
+		tokens, err := c.ListTokens(ctx)
+		if err != nil {
+			t.Fatalf("list tokens failed: %v", err)
+		}
+		current := 0
+		for _, token := range tokens {
+			if token.IsCurrent {
+				current++
+			}
+		}
+		if current != 1 {
+			t.Fatalf("expected exactly one current token, got %d", current)
+		}
+	})
+
+	t.Run("RevokeToken", func(t *testing.T) {
+		other := pcloud.NewClient(baseURL)
+		if err := other.Login(ctx, username, password); err != nil {
+			t.Fatalf("second login failed: %v", err)
+		}
+
+		tokens, err := other.ListTokens(ctx)
+		if err != nil {
+			t.Fatalf("list tokens failed: %v", err)
+		}
+		for _, token := range tokens {
+			if token.IsCurrent {
+				if err := c.RevokeToken(ctx, token.TokenID); err != nil {
+					t.Fatalf("revoke token failed: %v", err)
+				}
+			}
+		}
+
+		if _, err := other.UserInfo(ctx); err == nil {
+			t.Fatal("revoked session should no longer work")
+		}
+	})
+
+	t.Run("CredentialStore", func(t *testing.T) {
+		store := pcloud.NewMemoryCredentialStore()
+		if err := store.Save(ctx, c.Credentials()); err != nil {
+			t.Fatalf("save credentials failed: %v", err)
+		}
+
+		restored := pcloud.NewClient("")
+		if err := restored.SetCredentialStore(ctx, store); err != nil {
+			t.Fatalf("load credentials failed: %v", err)
+		}
+		if restored.AuthToken() != c.AuthToken() {
+			t.Fatal("auth token not restored")
+		}
+		if _, err := restored.UserInfo(ctx); err != nil {
+			t.Fatalf("userinfo with restored credentials failed: %v", err)
+		}
+	})
+
 	t.Run("Logout", func(t *testing.T) {
 		if err := c.Logout(ctx); err != nil {
 			t.Fatalf("logout failed: %v", err)
@@ -281,6 +356,45 @@ func TestRevisions(t *testing.T) {
 		}
 	})
 
+	t.Run("DownloadRevision", func(t *testing.T) {
+		revs, err := c.ListRevisions(ctx, fileID)
+		if err != nil {
+			t.Fatalf("list revisions failed: %v", err)
+		}
+		if len(revs) == 0 {
+			t.Skip("no revisions")
+		}
+
+		body, err := c.DownloadRevision(ctx, fileID, revs[len(revs)-1].RevisionID, nil)
+		if err != nil {
+			t.Fatalf("download revision failed: %v", err)
+		}
+		defer body.Close()
+
+		content, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatalf("read body failed: %v", err)
+		}
+		if !bytes.Equal(content, content1) {
+			t.Fatalf("expected v1 content, got %s", content)
+		}
+	})
+
+	t.Run("RevisionDiff", func(t *testing.T) {
+		revs, _ := c.ListRevisions(ctx, fileID)
+		if len(revs) < 2 {
+			t.Skip("not enough revisions")
+		}
+
+		diff, err := c.RevisionDiff(ctx, fileID, revs[len(revs)-1].RevisionID, revs[0].RevisionID)
+		if err != nil {
+			t.Fatalf("revision diff failed: %v", err)
+		}
+		if diff == "" {
+			t.Fatal("expected non-empty diff")
+		}
+	})
+
 	t.Run("RevertRevision", func(t *testing.T) {
 		revs, _ := c.ListRevisions(ctx, fileID)
 		if len(revs) < 2 {
@@ -407,3 +521,189 @@ func TestWalk(t *testing.T) {
 		}
 	})
 }
+
+func TestUploadLinks(t *testing.T) {
+	c, ctx := getClient(t)
+	defer c.Logout(ctx)
+
+	testFolder := "pcloud_uploadlink_test_" + time.Now().Format("20060102150405")
+	folder, err := c.CreateFolder(ctx, 0, testFolder)
+	if err != nil {
+		t.Fatalf("create test folder failed: %v", err)
+	}
+	defer c.DeleteFolderRecursive(ctx, folder.FolderID)
+
+	link, err := c.CreateUploadLink(ctx, folder.FolderID, "e2e test", &pcloud.UploadLinkOpts{MaxFiles: 5})
+	if err != nil {
+		t.Fatalf("create upload link failed: %v", err)
+	}
+	defer c.DeleteUploadLink(ctx, link.LinkID)
+
+	t.Run("ListUploadLinks", func(t *testing.T) {
+		links, err := c.ListUploadLinks(ctx)
+		if err != nil {
+			t.Fatalf("list upload links failed: %v", err)
+		}
+		found := false
+		for _, l := range links {
+			if l.Code == link.Code {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatal("created upload link not listed")
+		}
+	})
+
+	t.Run("ShowUploadLink", func(t *testing.T) {
+		info, err := c.ShowUploadLink(ctx, link.Code)
+		if err != nil {
+			t.Fatalf("show upload link failed: %v", err)
+		}
+		if info.Comment != "e2e test" {
+			t.Fatalf("expected comment e2e test, got %s", info.Comment)
+		}
+	})
+
+	t.Run("UploadToLink", func(t *testing.T) {
+		anon := pcloud.NewClient(os.Getenv("PCLOUD_BASE_URL"))
+		content := bytes.NewReader([]byte("uploaded via link"))
+		if err := anon.UploadToLink(ctx, link.Code, "e2e", "linked.txt", content, nil); err != nil {
+			t.Fatalf("upload to link failed: %v", err)
+		}
+	})
+
+	t.Run("ChangeUploadLink", func(t *testing.T) {
+		if err := c.ChangeUploadLink(ctx, link.LinkID, &pcloud.UploadLinkOpts{ClearMaxFiles: true}); err != nil {
+			t.Fatalf("change upload link failed: %v", err)
+		}
+	})
+}
+
+func TestPublicLinkContent(t *testing.T) {
+	c, ctx := getClient(t)
+	defer c.Logout(ctx)
+
+	testFolder := "pcloud_publink_test_" + time.Now().Format("20060102150405")
+	folder, err := c.CreateFolder(ctx, 0, testFolder)
+	if err != nil {
+		t.Fatalf("create test folder failed: %v", err)
+	}
+	defer c.DeleteFolderRecursive(ctx, folder.FolderID)
+
+	content := []byte("public content")
+	meta, err := c.Upload(ctx, folder.FolderID, "shared.txt", bytes.NewReader(content), nil)
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+
+	link, err := c.CreateFolderPublicLink(ctx, folder.FolderID, nil)
+	if err != nil {
+		t.Fatalf("create public link failed: %v", err)
+	}
+	defer c.DeletePublicLink(ctx, link.LinkID)
+
+	anon := pcloud.NewClient(os.Getenv("PCLOUD_BASE_URL"))
+
+	t.Run("WalkPublicLink", func(t *testing.T) {
+		var names []string
+		for item, err := range anon.WalkPublicLink(ctx, link.Code) {
+			if err != nil {
+				t.Fatalf("walk public link failed: %v", err)
+			}
+			names = append(names, item.Name)
+		}
+		if len(names) != 1 || names[0] != "shared.txt" {
+			t.Fatalf("unexpected items: %v", names)
+		}
+	})
+
+	t.Run("DownloadPublicLink", func(t *testing.T) {
+		body, err := anon.DownloadPublicLink(ctx, link.Code, meta.FileID, nil)
+		if err != nil {
+			t.Fatalf("download public link failed: %v", err)
+		}
+		defer body.Close()
+
+		got, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatalf("read body failed: %v", err)
+		}
+		if !bytes.Equal(got, content) {
+			t.Fatalf("content mismatch: got %s", got)
+		}
+	})
+
+	t.Run("DownloadPublicLinkZip", func(t *testing.T) {
+		body, err := anon.DownloadPublicLinkZip(ctx, link.Code, nil)
+		if err != nil {
+			t.Fatalf("download public zip failed: %v", err)
+		}
+		defer body.Close()
+
+		head := make([]byte, 2)
+		if _, err := io.ReadFull(body, head); err != nil {
+			t.Fatalf("read zip failed: %v", err)
+		}
+		if string(head) != "PK" {
+			t.Fatalf("not a zip archive: %q", head)
+		}
+	})
+
+	t.Run("CreateTreePublicLink", func(t *testing.T) {
+		tree, err := c.CreateTreePublicLink(ctx, "e2e tree", []uint64{folder.FolderID}, []uint64{meta.FileID}, nil)
+		if err != nil {
+			t.Fatalf("create tree public link failed: %v", err)
+		}
+		defer c.DeletePublicLink(ctx, tree.LinkID)
+
+		links, err := c.ListPublicLinks(ctx)
+		if err != nil {
+			t.Fatalf("list public links failed: %v", err)
+		}
+		kinds := make(map[uint64]pcloud.PublicLinkKind)
+		for _, l := range links {
+			kinds[l.LinkID] = l.Kind
+		}
+		if kinds[link.LinkID] != pcloud.PublicLinkFolder {
+			t.Fatalf("expected folder link, got %q", kinds[link.LinkID])
+		}
+		if kinds[tree.LinkID] != pcloud.PublicLinkTree {
+			t.Fatalf("expected tree link, got %q", kinds[tree.LinkID])
+		}
+	})
+
+	t.Run("ChangePublicLinkPassword", func(t *testing.T) {
+		changed, err := c.ChangePublicLink(ctx, link.LinkID, &pcloud.PublicLinkOpts{Password: "e2e-secret"})
+		if err != nil {
+			t.Fatalf("set password failed: %v", err)
+		}
+		if !changed.HasPassword {
+			t.Fatal("expected link to be password protected")
+		}
+
+		changed, err = c.ChangePublicLink(ctx, link.LinkID, &pcloud.PublicLinkOpts{ClearPassword: true})
+		if err != nil {
+			t.Fatalf("clear password failed: %v", err)
+		}
+		if changed.HasPassword {
+			t.Fatal("expected password to be cleared")
+		}
+	})
+
+	t.Run("GetPublicLinkStats", func(t *testing.T) {
+		if _, err := c.GetPublicLinkStats(ctx, link.LinkID, nil); err != nil {
+			t.Fatalf("get public link stats failed: %v", err)
+		}
+	})
+
+	t.Run("CopyPublicFile", func(t *testing.T) {
+		copied, err := c.CopyPublicFile(ctx, link.Code, meta.FileID, folder.FolderID, "imported.txt")
+		if err != nil {
+			t.Fatalf("copy public file failed: %v", err)
+		}
+		if copied.Name != "imported.txt" {
+			t.Fatalf("expected name imported.txt, got %s", copied.Name)
+		}
+	})
+}
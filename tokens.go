@@ -0,0 +1,72 @@
+package pcloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+type AuthTokenInfo struct {
+	TokenID   uint64 `json:"tokenid"`
+	Device    string `json:"device"`
+	Created   Time   `json:"created"`
+	Expires   Time   `json:"expires"`
+	IsCurrent bool   `json:"current"`
+}
+
+type listTokensResponse struct {
+	Error
+	Tokens []AuthTokenInfo `json:"tokens"`
+}
+
+func (c *Client) ListTokens(ctx context.Context) ([]AuthTokenInfo, error) {
+	var resp listTokensResponse
+	if err := c.do(ctx, "listtokens", url.Values{}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Tokens, nil
+}
+
+func (c *Client) RevokeToken(ctx context.Context, tokenID uint64) error {
+	params := url.Values{
+		"tokenid": {strconv.FormatUint(tokenID, 10)},
+	}
+
+	var resp Error
+	return c.do(ctx, "deletetoken", params, &resp)
+}
+
+// RevokeAllOtherTokens revokes every session except the caller's own. It
+// revokes nothing unless exactly one listed token is marked current, so a
+// changed response can never log the caller out too.
+func (c *Client) RevokeAllOtherTokens(ctx context.Context) (int, error) {
+	tokens, err := c.ListTokens(ctx)
+	if err != nil {
+		return 0, err
+	}
+	current := 0
+	for _, token := range tokens {
+		if token.IsCurrent {
+			current++
+		}
+	}
+	if current != 1 {
+		return 0, fmt.Errorf("listtokens: %d tokens marked current, want 1; nothing revoked", current)
+	}
+
+	revoked := 0
+	var errs []error
+	for _, token := range tokens {
+		if token.IsCurrent {
+			continue
+		}
+		if err := c.RevokeToken(ctx, token.TokenID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		revoked++
+	}
+	return revoked, errors.Join(errs...)
+}
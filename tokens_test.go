@@ -0,0 +1,65 @@
+package pcloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestRevokeAllOtherTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		tokens  string
+		revoked []string
+		wantErr bool
+	}{
+		{
+			name:    "one current",
+			tokens:  `[{"tokenid":1,"current":true},{"tokenid":2},{"tokenid":3,"current":false}]`,
+			revoked: []string{"2", "3"},
+		},
+		{
+			name:    "no current",
+			tokens:  `[{"tokenid":1,"iscurrent":true},{"tokenid":2}]`,
+			wantErr: true,
+		},
+		{
+			name:    "two current",
+			tokens:  `[{"tokenid":1,"current":true},{"tokenid":2,"current":true}]`,
+			wantErr: true,
+		},
+		{
+			name:    "empty list",
+			tokens:  `[]`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var revoked []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/listtokens":
+					fmt.Fprintf(w, `{"result":0,"tokens":%s}`, tt.tokens)
+				case "/deletetoken":
+					_ = r.ParseForm()
+					revoked = append(revoked, r.Form.Get("tokenid"))
+					fmt.Fprint(w, `{"result":0}`)
+				}
+			}))
+			defer srv.Close()
+
+			c := NewClient(srv.URL, WithRateLimit(60000))
+			n, err := c.RevokeAllOtherTokens(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(revoked, tt.revoked) || n != len(tt.revoked) {
+				t.Errorf("revoked %q (n=%d), want %q", revoked, n, tt.revoked)
+			}
+		})
+	}
+}
@@ -3,10 +3,11 @@ package pcloud
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 )
 
-type apiError interface {
+type APIResult interface {
 	Err() error
 }
 
@@ -14,6 +15,15 @@ type Time struct {
 	time.Time
 }
 
+// MarshalJSON encodes t in the API's RFC 1123 format, so marshalled values
+// can be read back by UnmarshalJSON. The zero time encodes as "".
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte(`""`), nil
+	}
+	return json.Marshal(t.Format(time.RFC1123Z))
+}
+
 func (t *Time) UnmarshalJSON(data []byte) error {
 	var s string
 	if err := json.Unmarshal(data, &s); err != nil {
@@ -30,20 +40,43 @@ func (t *Time) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-type Hash string
+// Hash is pCloud's 64-bit content hash. Files with the same content have
+// the same hash, so it is a cheap way to detect changes. Zero means the hash
+// is unknown.
+type Hash uint64
+
+func (h Hash) String() string {
+	return strconv.FormatUint(uint64(h), 10)
+}
+
+func (h Hash) IsZero() bool {
+	return h == 0
+}
+
+func (h Hash) MarshalJSON() ([]byte, error) {
+	return strconv.AppendUint(nil, uint64(h), 10), nil
+}
 
 func (h *Hash) UnmarshalJSON(data []byte) error {
-	var s string
-	if err := json.Unmarshal(data, &s); err == nil {
-		*h = Hash(s)
-		return nil
-	}
 	var n uint64
 	if err := json.Unmarshal(data, &n); err == nil {
-		*h = ""
+		*h = Hash(n)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("hash: cannot unmarshal %s", string(data))
+	}
+	if s == "" {
+		*h = 0
 		return nil
 	}
-	return fmt.Errorf("hash: cannot unmarshal %s", string(data))
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("hash: %w", err)
+	}
+	*h = Hash(n)
+	return nil
 }
 
 type Error struct {
@@ -78,9 +111,52 @@ type Metadata struct {
 	Size        uint64     `json:"size,omitempty"`
 	ContentType string     `json:"contenttype,omitempty"`
 	Hash        Hash       `json:"hash,omitempty"`
-	Category    int        `json:"category,omitempty"`
+	Category    Category   `json:"category,omitempty"`
 	Thumb       bool       `json:"thumb,omitempty"`
 	Contents    []Metadata `json:"contents,omitempty"`
+
+	// Media properties, set for files of the matching Category when pCloud
+	// returns them.
+	Image *ImageInfo `json:"-"`
+	Video *VideoInfo `json:"-"`
+	Audio *AudioInfo `json:"-"`
+}
+
+type metadataAlias Metadata
+
+type metadataJSON struct {
+	*metadataAlias
+	mediaJSON
+}
+
+func (m *Metadata) UnmarshalJSON(data []byte) error {
+	j := metadataJSON{metadataAlias: (*metadataAlias)(m)}
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	j.apply(m)
+	return nil
+}
+
+func (m Metadata) MarshalJSON() ([]byte, error) {
+	return json.Marshal(metadataJSON{
+		metadataAlias: (*metadataAlias)(&m),
+		mediaJSON:     newMediaJSON(&m),
+	})
+}
+
+// ContentChanged reports whether other holds different content than m. It
+// compares content hashes and falls back to size and modification time when
+// either hash is unknown. Two folders never differ in content, while a
+// folder and a file always do.
+func (m *Metadata) ContentChanged(other *Metadata) bool {
+	if m.IsFolder || other.IsFolder {
+		return m.IsFolder != other.IsFolder
+	}
+	if !m.Hash.IsZero() && !other.Hash.IsZero() {
+		return m.Hash != other.Hash
+	}
+	return m.Size != other.Size || !m.Modified.Equal(other.Modified.Time)
 }
 
 type Revision struct {
@@ -0,0 +1,322 @@
+package pcloud
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMetadataContentChanged(t *testing.T) {
+	now := Time{Time: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	later := Time{Time: now.Add(time.Hour)}
+
+	tests := []struct {
+		name     string
+		old, new Metadata
+		want     bool
+	}{
+		{"same hash", Metadata{Hash: 1, Size: 1}, Metadata{Hash: 1, Size: 2, Modified: later}, false},
+		{"different hash", Metadata{Hash: 1}, Metadata{Hash: 2}, true},
+		{"unknown hash, same size and time", Metadata{Size: 3, Modified: now}, Metadata{Hash: 5, Size: 3, Modified: now}, false},
+		{"unknown hash, different size", Metadata{Size: 3, Modified: now}, Metadata{Size: 4, Modified: now}, true},
+		{"unknown hash, different time", Metadata{Size: 3, Modified: now}, Metadata{Size: 3, Modified: later}, true},
+		{"two folders", Metadata{IsFolder: true, Modified: now}, Metadata{IsFolder: true, Modified: later}, false},
+		{"file replaced by folder", Metadata{Hash: 1}, Metadata{IsFolder: true}, true},
+		{"folder replaced by file", Metadata{IsFolder: true}, Metadata{Hash: 1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.new.ContentChanged(&tt.old); got != tt.want {
+				t.Errorf("ContentChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetadataMedia(t *testing.T) {
+	tests := []struct {
+		name  string
+		json  string
+		image *ImageInfo
+		video *VideoInfo
+		audio *AudioInfo
+	}{
+		{
+			name:  "image",
+			json:  `{"name":"a.jpg","category":1,"width":4000,"height":3000}`,
+			image: &ImageInfo{Width: 4000, Height: 3000},
+		},
+		{
+			name:  "image with string numbers",
+			json:  `{"name":"a.jpg","category":1,"width":"640","height":"480"}`,
+			image: &ImageInfo{Width: 640, Height: 480},
+		},
+		{
+			name:  "image with video fields",
+			json:  `{"name":"a.gif","category":1,"width":10,"height":20,"duration":"3.5","videocodec":"gif"}`,
+			image: &ImageInfo{Width: 10, Height: 20},
+		},
+		{
+			name: "image without dimensions",
+			json: `{"name":"a.jpg","category":1,"width":null,"height":""}`,
+		},
+		{
+			name: "video",
+			json: `{"name":"a.mp4","category":2,"width":"1920","height":1080,"duration":"12.5","fps":"29.97",` +
+				`"rotate":90,"videocodec":"h264","videobitrate":"4500000","audiocodec":"aac","audiobitrate":128000,` +
+				`"audiosamplerate":"44100"}`,
+			video: &VideoInfo{
+				Width:           1920,
+				Height:          1080,
+				Duration:        12500 * time.Millisecond,
+				FPS:             29.97,
+				Rotate:          90,
+				VideoCodec:      "h264",
+				VideoBitrate:    4500000,
+				AudioCodec:      "aac",
+				AudioBitrate:    128000,
+				AudioSampleRate: 44100,
+			},
+		},
+		{
+			name:  "video with nulls",
+			json:  `{"name":"a.mp4","category":2,"width":null,"height":null,"duration":"2","videocodec":"vp9"}`,
+			video: &VideoInfo{Duration: 2 * time.Second, VideoCodec: "vp9"},
+		},
+		{
+			name: "audio",
+			json: `{"name":"a.mp3","category":3,"artist":"Artist","album":"Album","title":"Title","genre":"Rock",` +
+				`"trno":"3/12","duration":"215.5"}`,
+			audio: &AudioInfo{
+				Artist:   "Artist",
+				Album:    "Album",
+				Title:    "Title",
+				Genre:    "Rock",
+				Track:    "3/12",
+				Duration: 215500 * time.Millisecond,
+			},
+		},
+		{
+			name:  "audio with numeric track",
+			json:  `{"name":"a.mp3","category":3,"title":"Title","trno":7,"duration":60}`,
+			audio: &AudioInfo{Title: "Title", Track: "7", Duration: time.Minute},
+		},
+		{
+			name:  "audio with null track",
+			json:  `{"name":"a.mp3","category":3,"artist":"Artist","trno":null}`,
+			audio: &AudioInfo{Artist: "Artist"},
+		},
+		{
+			name: "document",
+			json: `{"name":"a.pdf","category":4,"width":100,"title":"Report"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m Metadata
+			if err := json.Unmarshal([]byte(tt.json), &m); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(m.Image, tt.image) {
+				t.Errorf("Image = %+v, want %+v", m.Image, tt.image)
+			}
+			if !reflect.DeepEqual(m.Video, tt.video) {
+				t.Errorf("Video = %+v, want %+v", m.Video, tt.video)
+			}
+			if !reflect.DeepEqual(m.Audio, tt.audio) {
+				t.Errorf("Audio = %+v, want %+v", m.Audio, tt.audio)
+			}
+		})
+	}
+}
+
+func TestMetadataMediaInvalid(t *testing.T) {
+	for _, data := range []string{
+		`{"category":1,"width":"wide"}`,
+		`{"category":3,"trno":{}}`,
+	} {
+		var m Metadata
+		if err := json.Unmarshal([]byte(data), &m); err == nil {
+			t.Errorf("%s: expected an error", data)
+		}
+	}
+}
+
+func TestMetadataMediaMarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		meta Metadata
+		want map[string]any
+	}{
+		{
+			name: "image",
+			meta: Metadata{Category: CategoryImage, Image: &ImageInfo{Width: 640, Height: 480}},
+			want: map[string]any{"width": 640.0, "height": 480.0},
+		},
+		{
+			name: "video",
+			meta: Metadata{Category: CategoryVideo, Video: &VideoInfo{
+				Width:           1920,
+				Height:          1080,
+				Duration:        1500 * time.Millisecond,
+				FPS:             25,
+				VideoCodec:      "h264",
+				AudioSampleRate: 48000,
+			}},
+			want: map[string]any{
+				"width":           1920.0,
+				"height":          1080.0,
+				"duration":        1.5,
+				"fps":             25.0,
+				"videocodec":      "h264",
+				"audiosamplerate": 48000.0,
+			},
+		},
+		{
+			name: "audio",
+			meta: Metadata{Category: CategoryAudio, Audio: &AudioInfo{Artist: "Artist", Track: "2", Duration: time.Minute}},
+			want: map[string]any{"artist": "Artist", "trno": "2", "duration": 60.0},
+		},
+		{
+			name: "no media",
+			meta: Metadata{Category: CategoryDocument},
+			want: map[string]any{},
+		},
+	}
+	mediaKeys := []string{
+		"width", "height", "duration", "fps", "rotate", "videocodec", "videobitrate", "audiocodec",
+		"audiobitrate", "audiosamplerate", "artist", "album", "title", "genre", "trno",
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.meta)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var fields map[string]any
+			if err := json.Unmarshal(data, &fields); err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]any{}
+			for _, k := range mediaKeys {
+				if v, ok := fields[k]; ok {
+					got[k] = v
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("media fields = %v, want %v", got, tt.want)
+			}
+
+			var back Metadata
+			if err := json.Unmarshal(data, &back); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(back.Image, tt.meta.Image) || !reflect.DeepEqual(back.Video, tt.meta.Video) ||
+				!reflect.DeepEqual(back.Audio, tt.meta.Audio) {
+				t.Errorf("round trip = %+v %+v %+v", back.Image, back.Video, back.Audio)
+			}
+		})
+	}
+}
+
+func TestTimeMarshalJSON(t *testing.T) {
+	tests := []struct {
+		time Time
+		want string
+	}{
+		{Time{}, `""`},
+		{Time{Time: time.Date(2024, 3, 5, 14, 7, 9, 0, time.UTC)}, `"Tue, 05 Mar 2024 14:07:09 +0000"`},
+		{Time{Time: time.Date(2024, 3, 5, 14, 7, 9, 0, time.FixedZone("", 2*60*60))}, `"Tue, 05 Mar 2024 14:07:09 +0200"`},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.time)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("MarshalJSON(%v) = %s, want %s", tt.time, data, tt.want)
+		}
+
+		var back Time
+		if err := json.Unmarshal(data, &back); err != nil {
+			t.Fatal(err)
+		}
+		if !back.Equal(tt.time.Time) {
+			t.Errorf("round trip of %s = %v", data, back)
+		}
+	}
+}
+
+func TestMetadataJSONRoundTrip(t *testing.T) {
+	created := Time{Time: time.Date(2024, 3, 5, 14, 7, 9, 0, time.UTC)}
+	items := []Metadata{
+		{
+			ID:          "f42",
+			Name:        "clip.mp4",
+			Path:        "/videos/clip.mp4",
+			Created:     created,
+			Modified:    Time{Time: created.Add(time.Hour)},
+			IsMine:      true,
+			Icon:        "video",
+			FileID:      42,
+			ParentID:    7,
+			Size:        1 << 40,
+			ContentType: "video/mp4",
+			Hash:        Hash(18446744073709551615),
+			Category:    CategoryVideo,
+			Thumb:       true,
+			Video:       &VideoInfo{Width: 1280, Height: 720, Duration: 90 * time.Second, FPS: 30, VideoCodec: "h264"},
+		},
+		{
+			ID:       "f43",
+			Name:     "song.mp3",
+			FileID:   43,
+			Category: CategoryAudio,
+			Audio:    &AudioInfo{Artist: "Artist", Track: "1/10", Duration: 3 * time.Minute},
+		},
+		{
+			ID:       "f44",
+			Name:     "photo.jpg",
+			FileID:   44,
+			Category: CategoryImage,
+			Image:    &ImageInfo{Width: 100, Height: 50},
+		},
+		{
+			ID:       "d7",
+			Name:     "videos",
+			IsFolder: true,
+			IsShared: true,
+			FolderID: 7,
+			Contents: []Metadata{{ID: "f42", Name: "clip.mp4", FileID: 42, Created: created}},
+		},
+	}
+	for _, want := range items {
+		data, err := json.Marshal(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Metadata
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !equalMetadata(got, want) {
+			t.Errorf("round trip of %s:\ngot  %+v\nwant %+v", data, got, want)
+		}
+	}
+}
+
+// equalMetadata compares metadata, with times compared by instant.
+func equalMetadata(a, b Metadata) bool {
+	if !a.Created.Equal(b.Created.Time) || !a.Modified.Equal(b.Modified.Time) || len(a.Contents) != len(b.Contents) {
+		return false
+	}
+	for i := range a.Contents {
+		if !equalMetadata(a.Contents[i], b.Contents[i]) {
+			return false
+		}
+	}
+	a.Created, a.Modified, a.Contents = Time{}, Time{}, nil
+	b.Created, b.Modified, b.Contents = Time{}, Time{}, nil
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,171 @@
+package pcloud
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strconv"
+)
+
+type UploadLink struct {
+	Error
+	LinkID   uint64   `json:"uploadlinkid"`
+	FolderID uint64   `json:"folderid"`
+	Code     string   `json:"code"`
+	Link     string   `json:"link"`
+	Mail     string   `json:"mail"`
+	Comment  string   `json:"comment"`
+	Created  Time     `json:"created"`
+	Modified Time     `json:"modified"`
+	Expires  Time     `json:"expires,omitempty"`
+	Files    uint64   `json:"files"`
+	Space    uint64   `json:"space"`
+	MaxFiles uint64   `json:"maxfiles,omitempty"`
+	MaxSpace uint64   `json:"maxspace,omitempty"`
+	Metadata Metadata `json:"metadata"`
+}
+
+func (l *UploadLink) UnmarshalJSON(data []byte) error {
+	type uploadLink UploadLink
+	var raw struct {
+		uploadLink
+		ID uint64 `json:"id"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*l = UploadLink(raw.uploadLink)
+	if l.LinkID == 0 {
+		l.LinkID = raw.ID
+	}
+	return nil
+}
+
+type UploadLinkOpts struct {
+	ExpireTime    int64
+	MaxFiles      uint64
+	MaxSpace      uint64
+	ClearExpire   bool
+	ClearMaxFiles bool
+	ClearMaxSpace bool
+}
+
+// UploadToLinkOpts configures UploadToLink. The upload link endpoint takes
+// none of the UploadOpts flags, so only progress reporting is available.
+type UploadToLinkOpts struct {
+	OnProgress ProgressFunc
+}
+
+type listUploadLinksResponse struct {
+	Error
+	UploadLinks []UploadLink `json:"uploadlinks"`
+}
+
+func applyUploadLinkOpts(params url.Values, opts *UploadLinkOpts) {
+	if opts == nil {
+		return
+	}
+	if opts.ExpireTime > 0 {
+		params.Set("expire", strconv.FormatInt(opts.ExpireTime, 10))
+	}
+	if opts.MaxFiles > 0 {
+		params.Set("maxfiles", strconv.FormatUint(opts.MaxFiles, 10))
+	}
+	if opts.MaxSpace > 0 {
+		params.Set("maxspace", strconv.FormatUint(opts.MaxSpace, 10))
+	}
+	if opts.ClearExpire {
+		params.Set("deleteexpire", "1")
+	}
+	if opts.ClearMaxFiles {
+		params.Set("deletemaxfiles", "1")
+	}
+	if opts.ClearMaxSpace {
+		params.Set("deletemaxspace", "1")
+	}
+}
+
+func (c *Client) CreateUploadLink(ctx context.Context, folderID uint64, comment string, opts *UploadLinkOpts) (*UploadLink, error) {
+	params := url.Values{
+		"folderid": {strconv.FormatUint(folderID, 10)},
+		"comment":  {comment},
+	}
+	return c.createUploadLink(ctx, params, opts)
+}
+
+func (c *Client) CreateUploadLinkByPath(ctx context.Context, path, comment string, opts *UploadLinkOpts) (*UploadLink, error) {
+	params := url.Values{
+		"path":    {path},
+		"comment": {comment},
+	}
+	return c.createUploadLink(ctx, params, opts)
+}
+
+func (c *Client) createUploadLink(ctx context.Context, params url.Values, opts *UploadLinkOpts) (*UploadLink, error) {
+	applyUploadLinkOpts(params, opts)
+
+	var resp UploadLink
+	if err := c.do(ctx, "createuploadlink", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) ListUploadLinks(ctx context.Context) ([]UploadLink, error) {
+	var resp listUploadLinksResponse
+	if err := c.do(ctx, "listuploadlinks", url.Values{}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.UploadLinks, nil
+}
+
+func (c *Client) ChangeUploadLink(ctx context.Context, linkID uint64, opts *UploadLinkOpts) error {
+	params := url.Values{
+		"uploadlinkid": {strconv.FormatUint(linkID, 10)},
+	}
+	applyUploadLinkOpts(params, opts)
+
+	var resp Error
+	return c.do(ctx, "changeuploadlink", params, &resp)
+}
+
+func (c *Client) DeleteUploadLink(ctx context.Context, linkID uint64) error {
+	params := url.Values{
+		"uploadlinkid": {strconv.FormatUint(linkID, 10)},
+	}
+
+	var resp Error
+	return c.do(ctx, "deleteuploadlink", params, &resp)
+}
+
+func (c *Client) ShowUploadLink(ctx context.Context, code string) (*UploadLink, error) {
+	params := url.Values{
+		"code": {code},
+	}
+
+	var resp UploadLink
+	if err := c.do(ctx, "showuploadlink", params, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) UploadToLink(ctx context.Context, code, uploaderName, filename string, content io.Reader, opts *UploadToLinkOpts) error {
+	params := url.Values{
+		"code":  {code},
+		"names": {uploaderName},
+	}
+
+	var uploadOpts *UploadOpts
+	if opts != nil {
+		uploadOpts = &UploadOpts{OnProgress: opts.OnProgress}
+	}
+	body, contentType, err := multipartBody(filename, content, uploadOpts)
+	if err != nil {
+		return err
+	}
+
+	var resp Error
+	return c.doPost(ctx, "uploadtolink", params, body, contentType, &resp)
+}